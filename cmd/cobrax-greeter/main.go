@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/martinohmann/exp/cobrax"
+	"github.com/martinohmann/exp/output"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -18,6 +19,21 @@ type options struct {
 	verbose    bool
 	message    string
 	listenAddr string
+	output     *output.Config
+}
+
+// newOutputConfig returns the output config for greetings. Greetings are
+// printed as plain text by default.
+func newOutputConfig() *output.Config {
+	return &output.Config{
+		Format:          "gotemplate",
+		Template:        "{{.message}}",
+		TrailingNewline: true,
+	}
+}
+
+func greeting(opts *options) map[string]interface{} {
+	return map[string]interface{}{"message": opts.message}
 }
 
 func main() {
@@ -29,6 +45,7 @@ func main() {
 	opts := &options{
 		message:    "Hello World!",
 		listenAddr: "127.0.0.1:8080",
+		output:     newOutputConfig(),
 	}
 
 	cmd := newRootCommand(opts)
@@ -52,11 +69,13 @@ func newRootCommand(opts *options) *cobra.Command {
 				log.SetOutput(io.Discard)
 			}
 		},
-		Run: func(cmd *cobra.Command, args []string) {
-			cmd.Println(opts.message)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return output.Format(cmd.OutOrStdout(), greeting(opts), opts.output)
 		},
 	}
 
+	output.AddCommandFlags(cmd, opts.output)
+
 	cmd.PersistentFlags().BoolVar(&opts.verbose, "verbose", opts.verbose, "verbose output")
 	cmd.PersistentFlags().StringVarP(&opts.message, "message", "m", opts.message, "a nice greeting message")
 
@@ -72,12 +91,19 @@ func newServeCommand(opts *options) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			cmd.Printf("listening on %s\n", opts.listenAddr)
 
+			// Greetings are plain text by default. Clients can request
+			// other formats via the Accept header or the format query
+			// parameter, e.g. `?format=json`.
+			greeter := output.NewHandler(func(r *http.Request) (interface{}, error) {
+				return greeting(opts), nil
+			}, newOutputConfig())
+
 			handler := http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 				defer func(start time.Time) {
 					log.Printf("%s %s from %s took %v", r.Method, r.RequestURI, r.RemoteAddr, time.Since(start))
 				}(time.Now())
 
-				fmt.Fprintln(rw, opts.message)
+				greeter.ServeHTTP(rw, r)
 			})
 
 			return http.ListenAndServe(opts.listenAddr, handler)
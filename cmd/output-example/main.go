@@ -2,74 +2,72 @@
 package main
 
 import (
-	"bufio"
-	"encoding/xml"
 	"fmt"
-	"io"
 	"os"
-	"text/template"
+	"strings"
 
-	"github.com/ghodss/yaml"
 	"github.com/martinohmann/exit"
 	"github.com/martinohmann/exp/cli"
+	"github.com/martinohmann/exp/input"
 	"github.com/martinohmann/exp/output"
 	"github.com/martinohmann/exp/pflagx"
-	"github.com/mgutz/ansi"
 	"github.com/spf13/pflag"
 )
 
+// options holds flags which are specific to this example.
+type options struct {
+	outputFile string
+	diffFile   string
+	structural bool
+}
+
 func main() {
 	cli.Run(run)
 }
 
 func run() error {
-	formatters := output.DefaultFormatters.DeepCopy()
-
-	// Register custom format func.
-	formatters.RegisterFormatFunc("xml", func(v interface{}, config *output.Config) ([]byte, error) {
-		return xml.Marshal(v)
-	})
-
 	config := &output.Config{
-		Format:     "json",
-		Formatters: formatters,
-		Template:   `{{color "cyan"}}{{.}}{{color "reset"}}`,
+		Format:   "json",
+		Template: `{{color "cyan"}}{{.}}{{color "reset"}}`,
 		TemplateConfig: output.TemplateConfig{
-			// Custom template func definitions.
-			Funcs: template.FuncMap{
-				"color": ansi.ColorCode,
-			},
+			// Make built-in template funcs like color available.
+			DefaultFuncs: true,
 		},
 	}
 
-	args, err := parseArgs(config)
+	inputConfig := &input.Config{}
+
+	opts := &options{}
+
+	args, err := parseArgs(config, inputConfig, opts)
 	if err != nil {
 		return exit.Error(exit.CodeUsage, err)
 	}
 
-	rc, err := readCloser(args)
+	obj, err := decode(args, inputConfig)
 	if err != nil {
 		return err
 	}
-	defer rc.Close()
 
-	var obj interface{}
+	if opts.diffFile != "" {
+		return diff(opts, args, obj, config, inputConfig)
+	}
 
-	if err := decode(rc, &obj); err != nil {
-		return err
+	if opts.outputFile != "" {
+		return output.WriteFile(opts.outputFile, obj, config)
 	}
 
 	// This is doing the actual work in this example.
 	return output.Format(os.Stdout, obj, config)
 }
 
-func parseArgs(config *output.Config) ([]string, error) {
+func parseArgs(config *output.Config, inputConfig *input.Config, opts *options) ([]string, error) {
 	fs := pflag.NewFlagSet("output-example", pflag.ContinueOnError)
 
 	fs.Usage = func() {
-		fmt.Fprintln(os.Stderr, `usage: output-example [<file>] [flags]
+		fmt.Fprintln(os.Stderr, `usage: output-example [<file>...] [flags]
 
-Accepts json on stdin or from a file and prints it to stdout in a format dictated by the provided flags.
+Accepts json, yaml, toml, csv, tsv, json-lines or xml on stdin or from files and prints it to stdout or to the file given via --output-file in a format dictated by the provided flags. If multiple files are provided, their contents are formatted as a list.
 
 This is an example app for playing around with the github.com/martinohmann/exp/output package.
 
@@ -77,34 +75,54 @@ Flags:`)
 		fs.PrintDefaults()
 	}
 
-	fs.StringVarP(&config.Format, "output", "o", config.Format, "output format")
-	fs.StringVarP(&config.Template, "template", "t", config.Template, "output template. ignored unless output format is 'gotemplate'")
-	fs.StringVarP(&config.JSONPointer, "jsonpointer", "j", config.JSONPointer, "json pointer for filtering the data before formatting, e.g. '/foo/0/bar'")
-	fs.BoolVar(&config.TemplateItems, "items", config.TemplateItems, "if true, the template applies to the items if the input is a slice. ignored unless output format is 'gotemplate'")
-	fs.BoolVar(&config.TrailingNewline, "newline", config.TrailingNewline, "ensure output ends with a trailing newline")
+	fs.StringVarP(&inputConfig.Format, "input", "i", inputConfig.Format, "input format. detected from the file extension or the content if empty")
+	output.AddFlags(fs, config)
+	output.AddFileFlag(fs, &opts.outputFile)
 
-	pflagx.RegisterValidatorFunc(fs, "output", pflagx.AnyOf(output.FormatterNames()...))
+	fs.StringVar(&opts.diffFile, "diff", opts.diffFile, "print a diff between the contents of this file and the input instead of the formatted input")
+	fs.BoolVar(&opts.structural, "structural", opts.structural, "print a structural diff keyed by json pointers. ignored unless --diff is set")
+
+	pflagx.RegisterValidatorFunc(fs, "input", pflagx.AnyOf(input.DecoderNames()...))
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
 		return nil, err
 	}
 
+	if err := output.ValidateFlags(fs, config); err != nil {
+		return nil, err
+	}
+
 	return fs.Args(), nil
 }
 
-func readCloser(args []string) (io.ReadCloser, error) {
-	if len(args) == 0 || args[0] == "-" {
-		return io.NopCloser(bufio.NewReader(os.Stdin)), nil
+func decode(args []string, config *input.Config) (interface{}, error) {
+	if len(args) <= 1 {
+		name := "-"
+		if len(args) == 1 {
+			name = args[0]
+		}
+
+		return input.DecodeFile(name, config)
 	}
 
-	return os.Open(args[0])
+	return input.DecodeFiles(args, config)
 }
 
-func decode(r io.Reader, obj interface{}) error {
-	buf, err := io.ReadAll(r)
+// diff prints the differences between the contents of opts.diffFile and obj.
+func diff(opts *options, args []string, obj interface{}, config *output.Config, inputConfig *input.Config) error {
+	before, err := input.DecodeFile(opts.diffFile, inputConfig)
 	if err != nil {
 		return err
 	}
 
-	return yaml.Unmarshal(buf, obj)
+	toLabel := "<stdin>"
+	if len(args) > 0 {
+		toLabel = strings.Join(args, ", ")
+	}
+
+	return output.Diff(os.Stdout, before, obj, config, &output.DiffConfig{
+		Structural: opts.structural,
+		FromLabel:  opts.diffFile,
+		ToLabel:    toLabel,
+	})
 }
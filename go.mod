@@ -3,9 +3,12 @@ module github.com/martinohmann/exp
 go 1.16
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/fatih/color v1.10.0
 	github.com/ghodss/yaml v1.0.0
+	github.com/hashicorp/hcl v1.0.0
 	github.com/martinohmann/exit v0.0.8
+	github.com/mattn/go-isatty v0.0.12
 	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d
 	github.com/mitchellh/pointerstructure v1.2.0
 	github.com/mpolden/echoip v0.0.0-20210224195636-92a434d7eafd
@@ -13,4 +16,5 @@ require (
 	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.1
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,47 @@
+package input
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+func decodeCSV(r io.Reader, config *Config) (interface{}, error) {
+	return decodeDelimited(r, ',')
+}
+
+func decodeTSV(r io.Reader, config *Config) (interface{}, error) {
+	return decodeDelimited(r, '\t')
+}
+
+// decodeDelimited decodes delimiter separated values into a slice of objects.
+// The first record is treated as the header containing the object keys. All
+// values are decoded as strings.
+func decodeDelimited(r io.Reader, delimiter rune) (interface{}, error) {
+	cr := csv.NewReader(r)
+	cr.Comma = delimiter
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]interface{}, 0)
+
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+
+	for _, record := range records[1:] {
+		row := make(map[string]interface{}, len(header))
+
+		for i, key := range header {
+			row[key] = record[i]
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,252 @@
+// Package input decodes structured data from files or streams into generic
+// values. It is the counterpart of the output package: decoders are
+// registered by name in a DecoderMap and the format of the input can either
+// be provided explicitly or detected from the file extension or the content.
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Config configures the behaviour of the input decoder.
+type Config struct {
+	// Format optionally contains the name of the decoder that should be
+	// used. If empty, the format is detected from the file extension and
+	// falls back to content sniffing.
+	Format string
+	// Decoders can be set to configure user-defined decoders. If empty, the
+	// built-in decoders are used.
+	Decoders DecoderMap
+	// Extensions optionally maps file extensions including the leading dot
+	// to decoder names. If empty, DefaultExtensions is used.
+	Extensions map[string]string
+	// Stdin is read when the filename "-" is passed to DecodeFile or
+	// DecodeFiles. Defaults to os.Stdin if nil.
+	Stdin io.Reader
+}
+
+// DefaultExtensions maps file extensions to the names of the built-in
+// decoders.
+var DefaultExtensions = map[string]string{
+	".json":   "json",
+	".yaml":   "yaml",
+	".yml":    "yaml",
+	".toml":   "toml",
+	".csv":    "csv",
+	".tsv":    "tsv",
+	".jsonl":  "json-lines",
+	".ndjson": "json-lines",
+	".xml":    "xml",
+}
+
+// Decode reads all data from r and decodes it. If config.Format is empty,
+// the format is detected by sniffing the content.
+func Decode(r io.Reader, config *Config) (interface{}, error) {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeBytes(buf, config)
+}
+
+// DecodeBytes decodes buf. If config.Format is empty, the format is detected
+// by sniffing the content.
+func DecodeBytes(buf []byte, config *Config) (interface{}, error) {
+	return decode(buf, config.Format, config)
+}
+
+// DecodeFile reads and decodes the file with name. If name is "-", stdin is
+// read instead. If config.Format is empty, the format is detected from the
+// file extension with a fallback to content sniffing.
+func DecodeFile(name string, config *Config) (interface{}, error) {
+	buf, err := readFile(name, config)
+	if err != nil {
+		return nil, err
+	}
+
+	format := config.Format
+	if format == "" {
+		format, _ = formatFromExtension(name, config)
+	}
+
+	v, err := decode(buf, format, config)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", displayName(name), err)
+	}
+
+	return v, nil
+}
+
+// DecodeFiles decodes all named files via DecodeFile and returns the decoded
+// values in the order of names. If names is empty, stdin is decoded. The
+// format of each file is detected separately unless config.Format is set.
+func DecodeFiles(names []string, config *Config) ([]interface{}, error) {
+	if len(names) == 0 {
+		names = []string{"-"}
+	}
+
+	values := make([]interface{}, len(names))
+
+	for i, name := range names {
+		v, err := DecodeFile(name, config)
+		if err != nil {
+			return nil, err
+		}
+
+		values[i] = v
+	}
+
+	return values, nil
+}
+
+// DetectFormat detects the format of the file with name and content buf. The
+// file extension takes precedence over content sniffing and is looked up in
+// config.Extensions. Name may be empty if unknown. Config may be nil to use
+// the DefaultExtensions.
+func DetectFormat(name string, buf []byte, config *Config) string {
+	if format, ok := formatFromExtension(name, config); ok {
+		return format
+	}
+
+	return SniffFormat(buf)
+}
+
+// SniffFormat detects the format of buf by inspecting its content. Returns
+// one of "json", "json-lines", "xml", "toml" or "yaml". Since YAML is a
+// superset of JSON and the most lenient of the supported formats, it is used
+// as the fallback if no other format can be detected.
+func SniffFormat(buf []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(buf, []byte("\xef\xbb\xbf")))
+
+	switch {
+	case len(trimmed) == 0:
+		return "yaml"
+	case trimmed[0] == '<':
+		return "xml"
+	case json.Valid(trimmed):
+		return "json"
+	case (trimmed[0] == '{' || trimmed[0] == '[') && isJSONLines(trimmed):
+		return "json-lines"
+	case isTOML(trimmed):
+		return "toml"
+	default:
+		return "yaml"
+	}
+}
+
+func decode(buf []byte, format string, config *Config) (interface{}, error) {
+	if format == "" {
+		format = SniffFormat(buf)
+	}
+
+	decoders := config.Decoders
+	if len(decoders) == 0 {
+		decoders = DefaultDecoders
+	}
+
+	d, ok := decoders[format]
+	if !ok {
+		return nil, fmt.Errorf("no decoder for format %q", format)
+	}
+
+	return d.Decode(bytes.NewReader(buf), config)
+}
+
+func formatFromExtension(name string, config *Config) (string, bool) {
+	extensions := DefaultExtensions
+	if config != nil && len(config.Extensions) > 0 {
+		extensions = config.Extensions
+	}
+
+	format, ok := extensions[strings.ToLower(filepath.Ext(name))]
+	return format, ok
+}
+
+func readFile(name string, config *Config) ([]byte, error) {
+	if name != "-" {
+		return os.ReadFile(name)
+	}
+
+	stdin := config.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	return io.ReadAll(stdin)
+}
+
+func displayName(name string) string {
+	if name == "-" {
+		return "<stdin>"
+	}
+
+	return name
+}
+
+func isJSONLines(buf []byte) bool {
+	s := bufio.NewScanner(bytes.NewReader(buf))
+	s.Buffer(nil, len(buf)+1)
+
+	for s.Scan() {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) > 0 && !json.Valid(line) {
+			return false
+		}
+	}
+
+	return s.Err() == nil
+}
+
+var (
+	// tomlKey matches bare, quoted and dotted TOML keys.
+	tomlKey = `(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*')(?:\s*\.\s*(?:[A-Za-z0-9_-]+|"[^"]*"|'[^']*'))*`
+
+	// tomlHeaderRegexp matches TOML table and array of tables headers like
+	// `[foo.bar]` or `[[items]]`. Flow sequences in YAML like `[a, b]` do
+	// not match since keys cannot contain commas.
+	tomlHeaderRegexp = regexp.MustCompile(`^\[\s*` + tomlKey + `\s*\](\s*#.*)?$|^\[\[\s*` + tomlKey + `\s*\]\](\s*#.*)?$`)
+
+	// tomlKeyValueRegexp matches TOML key/value pairs like `foo = "bar"`.
+	// The value must start like a TOML value, so that YAML mappings with
+	// keys containing an equals sign like `a=b: c` do not match.
+	tomlKeyValueRegexp = regexp.MustCompile(`^` + tomlKey + `\s*=\s*(["'\[{]|[+-]?[0-9]|[+-]?(inf|nan)\b|(true|false)\b)`)
+)
+
+// isTOML returns true if buf looks like TOML. The first line which is
+// neither empty nor a comment must either be a key/value pair or a table
+// header. Since a header like `[foo]` is a valid flow sequence in YAML, a
+// header must be followed by another header or a key/value pair.
+func isTOML(buf []byte) bool {
+	header := false
+
+	for _, line := range strings.Split(string(buf), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		switch {
+		case tomlKeyValueRegexp.MatchString(line):
+			return true
+		case tomlHeaderRegexp.MatchString(line):
+			if header {
+				return true
+			}
+
+			header = true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
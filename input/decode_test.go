@@ -0,0 +1,123 @@
+package input
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "empty", input: "", want: "yaml"},
+		{name: "json object", input: ` {"foo": "bar"}`, want: "json"},
+		{name: "json array", input: "[1, 2]\n", want: "json"},
+		{name: "json lines", input: "{\"a\": 1}\n\n{\"a\": 2}\n", want: "json-lines"},
+		{name: "invalid json", input: `{"a": `, want: "yaml"},
+		{name: "yaml flow mapping", input: `{a: 1}`, want: "yaml"},
+		{name: "xml", input: "<?xml version=\"1.0\"?>\n<root/>", want: "xml"},
+		{name: "toml key", input: "# comment\n\nfoo = \"bar\"", want: "toml"},
+		{name: "toml table", input: "[foo.bar]\nbaz = 1", want: "toml"},
+		{name: "toml array of tables", input: "[[items]] # comment\n\n[[ items ]]\n", want: "toml"},
+		{name: "toml quoted keys", input: "[\"a b\".'c']\n\"d.e\" = 1", want: "toml"},
+		{name: "yaml flow sequence", input: "[a, b]\n", want: "yaml"},
+		{name: "yaml flow sequence with single item", input: "[a]\n", want: "yaml"},
+		{name: "yaml plain scalar", input: "a=b: c\n", want: "yaml"},
+		{name: "yaml", input: "foo: bar\n", want: "yaml"},
+		{name: "yaml documents", input: "---\nfoo: bar\n", want: "yaml"},
+		{name: "bom", input: "\xef\xbb\xbf{}", want: "json"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.want, SniffFormat([]byte(test.input)))
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	require.Equal(t, "yaml", DetectFormat("foo.YML", []byte(`{"a": 1}`), nil))
+	require.Equal(t, "json-lines", DetectFormat("foo.ndjson", nil, nil))
+	require.Equal(t, "json", DetectFormat("foo.txt", []byte(`{"a": 1}`), nil))
+	require.Equal(t, "json", DetectFormat("", []byte(`{"a": 1}`), nil))
+
+	config := &Config{Extensions: map[string]string{".txt": "csv"}}
+	require.Equal(t, "csv", DetectFormat("foo.txt", []byte(`{"a": 1}`), config))
+	require.Equal(t, "json", DetectFormat("foo.yml", []byte(`{"a": 1}`), config))
+}
+
+func TestDecode(t *testing.T) {
+	v, err := Decode(strings.NewReader("foo: bar\n"), &Config{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"foo": "bar"}, v)
+
+	v, err = DecodeBytes([]byte("foo = 1"), &Config{Format: "toml"})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"foo": json.Number("1")}, v)
+
+	_, err = DecodeBytes([]byte("foo"), &Config{Format: "ini"})
+	require.EqualError(t, err, `no decoder for format "ini"`)
+}
+
+func TestDecodeFile(t *testing.T) {
+	want := map[string]interface{}{"name": "foo", "count": json.Number("1")}
+
+	for _, name := range []string{"config.json", "config.yml", "config.toml"} {
+		t.Run(name, func(t *testing.T) {
+			v, err := DecodeFile("testdata/"+name, &Config{})
+			require.NoError(t, err)
+			require.Equal(t, want, v)
+		})
+	}
+
+	t.Run("sniffed", func(t *testing.T) {
+		v, err := DecodeFile("testdata/config.txt", &Config{})
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"name": "foo"}, v)
+	})
+
+	t.Run("custom extensions", func(t *testing.T) {
+		_, err := DecodeFile("testdata/config.txt", &Config{Extensions: map[string]string{".txt": "json"}})
+		require.EqualError(t, err, "testdata/config.txt: invalid character 'a' in literal null (expecting 'u')")
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		v, err := DecodeFile("-", &Config{Stdin: strings.NewReader(`[1, "two"]`)})
+		require.NoError(t, err)
+		require.Equal(t, []interface{}{json.Number("1"), "two"}, v)
+	})
+
+	t.Run("stdin error", func(t *testing.T) {
+		_, err := DecodeFile("-", &Config{Format: "json", Stdin: strings.NewReader(`{"a": 1} x`)})
+		require.EqualError(t, err, "<stdin>: unexpected data after top-level value")
+	})
+
+	t.Run("nonexistent", func(t *testing.T) {
+		_, err := DecodeFile("testdata/nonexistent.json", &Config{})
+		require.Error(t, err)
+	})
+}
+
+func TestDecodeFiles(t *testing.T) {
+	values, err := DecodeFiles([]string{"testdata/config.json", "-", "testdata/data.csv"}, &Config{
+		Stdin: strings.NewReader("foo: bar"),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "foo", "count": json.Number("1")},
+		map[string]interface{}{"foo": "bar"},
+		[]interface{}{
+			map[string]interface{}{"name": "foo", "count": "1"},
+			map[string]interface{}{"name": "bar", "count": "2"},
+		},
+	}, values)
+
+	values, err = DecodeFiles(nil, &Config{Stdin: strings.NewReader("1")})
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{json.Number("1")}, values)
+}
@@ -0,0 +1,232 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ghodss/yaml"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+// Decoder can decode values.
+type Decoder interface {
+	// Decode decodes the data read from r into a generic value. The Decoder
+	// may change behaviour depending on the passed in config.
+	Decode(r io.Reader, config *Config) (interface{}, error)
+}
+
+// DecodeFunc is a func that implements the Decoder interface.
+type DecodeFunc func(r io.Reader, config *Config) (interface{}, error)
+
+// Decode implements the Decoder interface.
+func (f DecodeFunc) Decode(r io.Reader, config *Config) (interface{}, error) {
+	return f(r, config)
+}
+
+// DecoderMap maps a user-defined name to a decoder.
+type DecoderMap map[string]Decoder
+
+// RegisterDecoder registers a Decoder. Panics if a decoder with the same name
+// already exists.
+func (m DecoderMap) RegisterDecoder(name string, d Decoder) {
+	if _, exists := m[name]; exists {
+		panic(fmt.Sprintf("decoder with name %q already registered", name))
+	}
+
+	m[name] = d
+}
+
+// RegisterDecodeFunc registers a DecodeFunc. Panics if a decoder with the
+// same name already exists.
+func (m DecoderMap) RegisterDecodeFunc(name string, f DecodeFunc) {
+	m.RegisterDecoder(name, f)
+}
+
+// Names returns a sorted slice of decoder names. This is useful to present
+// allowed values in command line flags.
+func (m DecoderMap) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// DeepCopy copies m. Returns a new DecoderMap which contains all entries from
+// m.
+func (m DecoderMap) DeepCopy() DecoderMap {
+	c := make(DecoderMap, len(m))
+	for name, decoder := range m {
+		c[name] = decoder
+	}
+
+	return c
+}
+
+// DefaultDecoders is a map of built-in decoders which can be extended to make
+// more decoders globally available. These are used as a fallback if the user
+// does not provide a custom map as part of the config to Decode* funcs.
+//
+// All built-in decoders produce values consisting only of
+// map[string]interface{}, []interface{}, string, json.Number, bool and nil,
+// which is the same representation the output package uses internally.
+var DefaultDecoders = DecoderMap{
+	"json":       DecodeFunc(decodeJSON),
+	"yaml":       DecodeFunc(decodeYAML),
+	"toml":       DecodeFunc(decodeTOML),
+	"json-lines": DecodeFunc(decodeJSONLines),
+	"csv":        DecodeFunc(decodeCSV),
+	"tsv":        DecodeFunc(decodeTSV),
+	"xml":        DecodeFunc(decodeXML),
+}
+
+// RegisterDecoder globally registers a Decoder. Panics if a decoder with the
+// same name already exists.
+func RegisterDecoder(name string, d Decoder) {
+	DefaultDecoders.RegisterDecoder(name, d)
+}
+
+// RegisterDecodeFunc globally registers a DecodeFunc. Panics if a decoder
+// with the same name already exists.
+func RegisterDecodeFunc(name string, f DecodeFunc) {
+	DefaultDecoders.RegisterDecodeFunc(name, f)
+}
+
+// DecoderNames returns a sorted slice of globally registered decoder names.
+// This is useful to present allowed values in command line flags.
+func DecoderNames() []string {
+	return DefaultDecoders.Names()
+}
+
+func decodeJSON(r io.Reader, config *Config) (interface{}, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	var v interface{}
+
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	// Ensure that there is no trailing data after the value.
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after top-level value")
+	}
+
+	return v, nil
+}
+
+// decodeYAML decodes YAML documents. If the input contains multiple documents
+// a slice of all documents is returned.
+func decodeYAML(r io.Reader, config *Config) (interface{}, error) {
+	dec := yamlv2.NewDecoder(r)
+
+	var docs []interface{}
+
+	for {
+		var doc interface{}
+
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := yamlToGeneric(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		docs = append(docs, v)
+	}
+
+	switch len(docs) {
+	case 0:
+		return nil, nil
+	case 1:
+		return docs[0], nil
+	default:
+		return docs, nil
+	}
+}
+
+// yamlToGeneric converts a document decoded by yaml.v2, which may contain
+// map[interface{}]interface{}, into its generic representation.
+func yamlToGeneric(doc interface{}) (interface{}, error) {
+	buf, err := yamlv2.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonBuf, err := yaml.YAMLToJSON(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeJSON(jsonBuf)
+}
+
+func decodeTOML(r io.Reader, config *Config) (interface{}, error) {
+	var m map[string]interface{}
+
+	if _, err := toml.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	return normalize(m)
+}
+
+// decodeJSONLines decodes newline delimited JSON values into a slice. Empty
+// lines are ignored.
+func decodeJSONLines(r io.Reader, config *Config) (interface{}, error) {
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64*1024*1024)
+
+	values := make([]interface{}, 0)
+
+	for n := 1; s.Scan(); n++ {
+		line := bytes.TrimSpace(s.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		v, err := normalizeJSON(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		values = append(values, v)
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// normalize converts v into its generic representation via a JSON
+// roundtrip.
+func normalize(v interface{}) (interface{}, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return normalizeJSON(buf)
+}
+
+func normalizeJSON(buf []byte) (interface{}, error) {
+	return decodeJSON(bytes.NewReader(buf), nil)
+}
@@ -0,0 +1,135 @@
+package input
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type decodeTestCase struct {
+	name   string
+	format string
+	input  string
+	want   interface{}
+	err    error
+}
+
+func testDecode(t *testing.T, tests []decodeTestCase) {
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := DecodeBytes([]byte(test.input), &Config{Format: test.format})
+			if test.err != nil {
+				require.Error(t, err)
+				require.Equal(t, test.err.Error(), err.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.want, v)
+			}
+		})
+	}
+}
+
+func TestDecoders(t *testing.T) {
+	tests := []decodeTestCase{
+		{
+			name:   "json numbers",
+			format: "json",
+			input:  `{"int": 12345678901234567890, "float": 1.5}`,
+			want:   map[string]interface{}{"int": json.Number("12345678901234567890"), "float": json.Number("1.5")},
+		},
+		{
+			name:   "yaml single document",
+			format: "yaml",
+			input:  "---\nfoo: [1, true, null]\n1: bar\n",
+			want: map[string]interface{}{
+				"foo": []interface{}{json.Number("1"), true, nil},
+				"1":   "bar",
+			},
+		},
+		{
+			name:   "yaml multiple documents",
+			format: "yaml",
+			input:  "foo: bar\n---\n- baz\n---\n42\n",
+			want: []interface{}{
+				map[string]interface{}{"foo": "bar"},
+				[]interface{}{"baz"},
+				json.Number("42"),
+			},
+		},
+		{
+			name:   "yaml empty",
+			format: "yaml",
+			input:  "",
+			want:   nil,
+		},
+		{
+			name:   "yaml invalid",
+			format: "yaml",
+			input:  "foo: [",
+			err:    errors.New("yaml: line 1: did not find expected node content"),
+		},
+		{
+			name:   "toml",
+			format: "toml",
+			input:  "title = \"foo\"\n\n[owner]\nage = 42\n\n[[items]]\nname = \"a\"\n",
+			want: map[string]interface{}{
+				"title": "foo",
+				"owner": map[string]interface{}{"age": json.Number("42")},
+				"items": []interface{}{map[string]interface{}{"name": "a"}},
+			},
+		},
+		{
+			name:   "json-lines",
+			format: "json-lines",
+			input:  "{\"a\": 1}\n\n[2]\n\"three\"\n",
+			want: []interface{}{
+				map[string]interface{}{"a": json.Number("1")},
+				[]interface{}{json.Number("2")},
+				"three",
+			},
+		},
+		{
+			name:   "json-lines invalid",
+			format: "json-lines",
+			input:  "{\"a\": 1}\n{",
+			err:    errors.New("line 2: unexpected EOF"),
+		},
+		{
+			name:   "csv",
+			format: "csv",
+			input:  "name,count\n\"foo, bar\",1\n",
+			want:   []interface{}{map[string]interface{}{"name": "foo, bar", "count": "1"}},
+		},
+		{
+			name:   "csv header only",
+			format: "csv",
+			input:  "name,count\n",
+			want:   []interface{}{},
+		},
+		{
+			name:   "tsv",
+			format: "tsv",
+			input:  "name\tcount\nfoo\t1\n",
+			want:   []interface{}{map[string]interface{}{"name": "foo", "count": "1"}},
+		},
+		{
+			name:   "csv wrong number of fields",
+			format: "csv",
+			input:  "name,count\nfoo\n",
+			err:    errors.New("record on line 2: wrong number of fields"),
+		},
+	}
+
+	testDecode(t, tests)
+}
+
+func TestDecoderMap(t *testing.T) {
+	m := DefaultDecoders.DeepCopy()
+	m.RegisterDecodeFunc("noop", nil)
+
+	require.Contains(t, m.Names(), "noop")
+	require.NotContains(t, DecoderNames(), "noop")
+	require.Panics(t, func() { m.RegisterDecodeFunc("json", nil) })
+}
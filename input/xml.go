@@ -0,0 +1,169 @@
+package input
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+// xmlNode is an element of a generic XML document.
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// decodeXML decodes an XML document into a generic value. It is the inverse
+// of the xml formatter of the output package:
+//
+//   - The root element is discarded and its content is returned.
+//   - Child elements become object keys. Repeated elements with the same
+//     name are collected into an array.
+//   - Elements whose children are all named "item" become arrays.
+//   - `<entry key="...">` elements use the value of the key attribute as
+//     object key.
+//   - Other attributes become object keys prefixed with "@". Character data
+//     of elements that also have attributes or children is stored under the
+//     "#text" key.
+//   - Elements without attributes, children and character data decode to
+//     nil. All other scalars are decoded as strings.
+func decodeXML(r io.Reader, config *Config) (interface{}, error) {
+	dec := xml.NewDecoder(r)
+
+	var (
+		root  *xmlNode
+		stack []*xmlNode
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: tok.Name.Local, attrs: tok.Attr}
+
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root != nil {
+				return nil, errors.New("xml: document contains multiple root elements")
+			} else {
+				root = node
+			}
+
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text.Write(tok)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, nil
+	}
+
+	return root.value(), nil
+}
+
+// key returns the object key of n.
+func (n *xmlNode) key() string {
+	if n.name == "entry" {
+		for _, attr := range n.attrs {
+			if attr.Name.Local == "key" {
+				return attr.Value
+			}
+		}
+	}
+
+	return n.name
+}
+
+// attributes returns the attributes of n excluding the key attribute of
+// entry elements.
+func (n *xmlNode) attributes() []xml.Attr {
+	if n.key() == n.name {
+		return n.attrs
+	}
+
+	attrs := make([]xml.Attr, 0, len(n.attrs))
+	for _, attr := range n.attrs {
+		if attr.Name.Local != "key" {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	return attrs
+}
+
+func (n *xmlNode) value() interface{} {
+	text := strings.TrimSpace(n.text.String())
+	attrs := n.attributes()
+
+	if len(n.children) == 0 && len(attrs) == 0 {
+		if text == "" {
+			return nil
+		}
+
+		return text
+	}
+
+	if len(attrs) == 0 && n.isArray() {
+		items := make([]interface{}, len(n.children))
+		for i, child := range n.children {
+			items[i] = child.value()
+		}
+
+		return items
+	}
+
+	m := make(map[string]interface{}, len(n.children)+len(attrs))
+
+	for _, attr := range attrs {
+		m["@"+attr.Name.Local] = attr.Value
+	}
+
+	if text != "" {
+		m["#text"] = text
+	}
+
+	// Group children by key to collect repeated elements into arrays.
+	groups := make(map[string][]interface{}, len(n.children))
+
+	for _, child := range n.children {
+		key := child.key()
+		groups[key] = append(groups[key], child.value())
+	}
+
+	for key, values := range groups {
+		if len(values) == 1 {
+			m[key] = values[0]
+		} else {
+			m[key] = values
+		}
+	}
+
+	return m
+}
+
+// isArray returns true if all children of n are item elements.
+func (n *xmlNode) isArray() bool {
+	for _, child := range n.children {
+		if child.name != "item" {
+			return false
+		}
+	}
+
+	return true
+}
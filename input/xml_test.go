@@ -0,0 +1,84 @@
+package input
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/martinohmann/exp/output"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeXML(t *testing.T) {
+	tests := []decodeTestCase{
+		{
+			name:   "empty",
+			format: "xml",
+			input:  "",
+			want:   nil,
+		},
+		{
+			name:   "scalar",
+			format: "xml",
+			input:  "<root> foo </root>",
+			want:   "foo",
+		},
+		{
+			name:   "repeated elements and attributes",
+			format: "xml",
+			input: `<?xml version="1.0"?>
+<library>
+  <book id="1"><title>Foo</title></book>
+  <book id="2" lang="en">Bar</book>
+  <empty/>
+</library>`,
+			want: map[string]interface{}{
+				"book": []interface{}{
+					map[string]interface{}{"@id": "1", "title": "Foo"},
+					map[string]interface{}{"@id": "2", "@lang": "en", "#text": "Bar"},
+				},
+				"empty": nil,
+			},
+		},
+		{
+			name:   "items and entries",
+			format: "xml",
+			input:  `<root><list><item>a</item><item><entry key="b c">d</entry></item></list></root>`,
+			want: map[string]interface{}{
+				"list": []interface{}{"a", map[string]interface{}{"b c": "d"}},
+			},
+		},
+		{
+			name:   "multiple root elements",
+			format: "xml",
+			input:  "<a/><b/>",
+			err:    errors.New("xml: document contains multiple root elements"),
+		},
+		{
+			name:   "invalid",
+			format: "xml",
+			input:  "<a>",
+			err:    errors.New("XML syntax error on line 1: unexpected EOF"),
+		},
+	}
+
+	testDecode(t, tests)
+}
+
+func TestDecodeXML_roundtrip(t *testing.T) {
+	v := map[string]interface{}{
+		"name":  "foo",
+		"tags":  []interface{}{"a", "b"},
+		"1st":   "entry",
+		"empty": nil,
+		"nested": map[string]interface{}{
+			"items": []interface{}{map[string]interface{}{"x": "y"}},
+		},
+	}
+
+	buf, err := output.FormatBytes(v, &output.Config{Format: "xml"})
+	require.NoError(t, err)
+
+	got, err := DecodeBytes(buf, &Config{})
+	require.NoError(t, err)
+	require.Equal(t, v, got)
+}
@@ -0,0 +1,274 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Filter is a parsed filter predicate as used in filter selectors, e.g.
+// `@.price < 10 && @.tags`. A *Filter is safe for concurrent use.
+type Filter struct {
+	expr string
+	e    expr
+}
+
+// ParseFilter parses expr into a *Filter. The expression has the same syntax
+// as the content of a filter selector `[?(...)]`. Both `@` and `$` refer to
+// the value the filter is matched against. Returns an error if expr is not a
+// valid filter expression.
+func ParseFilter(expr string) (*Filter, error) {
+	p := &parser{input: expr}
+
+	e, err := p.parseFilter()
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", expr, err)
+	}
+
+	return &Filter{expr: expr, e: e}, nil
+}
+
+// MustParseFilter is like ParseFilter but panics if expr cannot be parsed.
+func MustParseFilter(expr string) *Filter {
+	f, err := ParseFilter(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// Match returns true if v matches the filter.
+func (f *Filter) Match(v interface{}) bool {
+	return f.e.eval(v, v)
+}
+
+// String returns the original expression of f.
+func (f *Filter) String() string {
+	return f.expr
+}
+
+// expr is a boolean filter expression.
+type expr interface {
+	// eval evaluates the expression against the current node. The root
+	// node is used to resolve absolute paths.
+	eval(root, current interface{}) bool
+}
+
+type orExpr struct {
+	left, right expr
+}
+
+func (e orExpr) eval(root, current interface{}) bool {
+	return e.left.eval(root, current) || e.right.eval(root, current)
+}
+
+type andExpr struct {
+	left, right expr
+}
+
+func (e andExpr) eval(root, current interface{}) bool {
+	return e.left.eval(root, current) && e.right.eval(root, current)
+}
+
+type notExpr struct {
+	expr expr
+}
+
+func (e notExpr) eval(root, current interface{}) bool {
+	return !e.expr.eval(root, current)
+}
+
+// testExpr tests a single operand. Paths are true if they match at least one
+// value, literals are true unless they are null or false.
+type testExpr struct {
+	operand operand
+}
+
+func (e testExpr) eval(root, current interface{}) bool {
+	if path, ok := e.operand.(pathOperand); ok {
+		return len(path.nodes(root, current)) > 0
+	}
+
+	v, _ := e.operand.value(root, current)
+
+	return v != nil && v != false
+}
+
+type comparisonExpr struct {
+	op          string
+	left, right operand
+}
+
+func (e comparisonExpr) eval(root, current interface{}) bool {
+	left, lok := e.left.value(root, current)
+	right, rok := e.right.value(root, current)
+
+	if !lok || !rok {
+		// Comparisons involving paths that do not resolve to exactly one
+		// value are only equal if both sides are missing.
+		switch e.op {
+		case "==":
+			return lok == rok
+		case "!=":
+			return lok != rok
+		default:
+			return false
+		}
+	}
+
+	switch e.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	}
+
+	cmp, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+
+	switch e.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+type matchExpr struct {
+	left operand
+	re   *regexp.Regexp
+}
+
+func (e matchExpr) eval(root, current interface{}) bool {
+	v, ok := e.left.value(root, current)
+	if !ok {
+		return false
+	}
+
+	s, ok := v.(string)
+
+	return ok && e.re.MatchString(s)
+}
+
+// operand is an operand of a filter expression.
+type operand interface {
+	// value returns the value of the operand. The second return value is
+	// false if the operand does not resolve to exactly one value.
+	value(root, current interface{}) (interface{}, bool)
+}
+
+type pathOperand struct {
+	absolute bool
+	segments []segment
+}
+
+func (o pathOperand) nodes(root, current interface{}) []interface{} {
+	if o.absolute {
+		return evalSegments(o.segments, root, root)
+	}
+
+	return evalSegments(o.segments, root, current)
+}
+
+func (o pathOperand) value(root, current interface{}) (interface{}, bool) {
+	nodes := o.nodes(root, current)
+	if len(nodes) != 1 {
+		return nil, false
+	}
+
+	return nodes[0], true
+}
+
+type literalOperand struct {
+	v interface{}
+}
+
+func (o literalOperand) value(root, current interface{}) (interface{}, bool) {
+	return o.v, true
+}
+
+type regexpOperand struct {
+	re *regexp.Regexp
+}
+
+func (o regexpOperand) value(root, current interface{}) (interface{}, bool) {
+	return o.re.String(), true
+}
+
+// equal returns true if a and b are equal. Numbers of different types are
+// compared by value.
+func equal(a, b interface{}) bool {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		return ok && af == bf
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// compare compares two numbers or two strings. Returns false if a and b are
+// not comparable.
+func compare(a, b interface{}) (int, bool) {
+	if af, ok := toFloat(a); ok {
+		bf, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+
+		switch {
+		case af < bf:
+			return -1, true
+		case af > bf:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	as, ok := a.(string)
+	if !ok {
+		return 0, false
+	}
+
+	bs, ok := b.(string)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case as < bs:
+		return -1, true
+	case as > bs:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		return f, err == nil
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,354 @@
+// Package jsonpath implements JSONPath expressions for querying generic values
+// as produced by decoding JSON into an interface{}.
+//
+// Supported are the root (`$`) and current node (`@`) identifiers, child
+// access by name (`.name`, `['name']`), wildcards (`.*`, `[*]`), recursive
+// descent (`..name`, `..*`), array indices (`[0]`, `[-1]`), unions
+// (`[0,2]`, `['a','b']`), array slices (`[start:end:step]`) and filter
+// predicates (`[?(@.price < 10 && @.tags)]`). Filter predicates support the
+// comparison operators `==`, `!=`, `<`, `<=`, `>`, `>=` and `=~` (regular
+// expression match), the logical operators `&&`, `||` and `!` as well as
+// parentheses for grouping. Filter predicates can also be matched against
+// values on their own via ParseFilter.
+//
+// The leading `$` may be omitted, e.g. `items[*].name` is equivalent to
+// `$.items[*].name`.
+package jsonpath
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Path is a parsed JSONPath expression. A *Path is safe for concurrent use.
+type Path struct {
+	expr     string
+	segments []segment
+}
+
+// Parse parses expr into a *Path. Returns an error if expr is not a valid
+// JSONPath expression.
+func Parse(expr string) (*Path, error) {
+	p := &parser{input: expr}
+
+	segments, err := p.parseQuery()
+	if err != nil {
+		return nil, fmt.Errorf("invalid jsonpath expression %q: %w", expr, err)
+	}
+
+	return &Path{expr: expr, segments: segments}, nil
+}
+
+// MustParse is like Parse but panics if expr cannot be parsed.
+func MustParse(expr string) *Path {
+	p, err := Parse(expr)
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// Get parses expr and returns all values in v that match it. See
+// (*Path).Get for more information.
+func Get(v interface{}, expr string) ([]interface{}, error) {
+	p, err := Parse(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Get(v), nil
+}
+
+// String returns the original expression of p.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// Definite returns true if p can match at most one value, that is, if it does
+// not contain any wildcards, unions, slices, filters or recursive descent.
+func (p *Path) Definite() bool {
+	for _, seg := range p.segments {
+		if !seg.definite() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Get returns all values in v that match p in document order. Map values are
+// visited in the order of their sorted keys. Returns an empty slice if nothing
+// matches.
+//
+// Values are expected to be composed of maps with string keys, slices and
+// scalars. Other types of maps and slices are supported via reflection.
+func (p *Path) Get(v interface{}) []interface{} {
+	return evalSegments(p.segments, v, v)
+}
+
+func evalSegments(segments []segment, root, v interface{}) []interface{} {
+	nodes := []interface{}{v}
+
+	for _, seg := range segments {
+		next := make([]interface{}, 0, len(nodes))
+
+		for _, node := range nodes {
+			next = seg.apply(root, node, next)
+		}
+
+		nodes = next
+	}
+
+	return nodes
+}
+
+// segment is a single step of a path consisting of one or more selectors.
+type segment struct {
+	descendant bool
+	selectors  []selector
+}
+
+func (s segment) definite() bool {
+	if s.descendant || len(s.selectors) != 1 {
+		return false
+	}
+
+	switch s.selectors[0].(type) {
+	case nameSelector, indexSelector:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s segment) apply(root, v interface{}, out []interface{}) []interface{} {
+	if !s.descendant {
+		for _, sel := range s.selectors {
+			out = sel.selectFrom(root, v, out)
+		}
+
+		return out
+	}
+
+	for _, node := range descendants(v, nil) {
+		for _, sel := range s.selectors {
+			out = sel.selectFrom(root, node, out)
+		}
+	}
+
+	return out
+}
+
+// descendants returns v and all of its descendants in document order.
+func descendants(v interface{}, out []interface{}) []interface{} {
+	out = append(out, v)
+
+	for _, child := range children(v) {
+		out = descendants(child, out)
+	}
+
+	return out
+}
+
+type selector interface {
+	selectFrom(root, v interface{}, out []interface{}) []interface{}
+}
+
+type nameSelector string
+
+func (s nameSelector) selectFrom(root, v interface{}, out []interface{}) []interface{} {
+	if value, ok := lookupKey(v, string(s)); ok {
+		out = append(out, value)
+	}
+
+	return out
+}
+
+type wildcardSelector struct{}
+
+func (wildcardSelector) selectFrom(root, v interface{}, out []interface{}) []interface{} {
+	return append(out, children(v)...)
+}
+
+type indexSelector int
+
+func (s indexSelector) selectFrom(root, v interface{}, out []interface{}) []interface{} {
+	rv, ok := sliceValue(v)
+	if !ok {
+		return out
+	}
+
+	i := int(s)
+	if i < 0 {
+		i += rv.Len()
+	}
+
+	if i < 0 || i >= rv.Len() {
+		return out
+	}
+
+	return append(out, rv.Index(i).Interface())
+}
+
+type sliceSelector struct {
+	start, end *int
+	step       int
+}
+
+func (s sliceSelector) selectFrom(root, v interface{}, out []interface{}) []interface{} {
+	rv, ok := sliceValue(v)
+	if !ok || s.step == 0 {
+		return out
+	}
+
+	n := rv.Len()
+
+	normalize := func(i int) int {
+		if i < 0 {
+			return i + n
+		}
+
+		return i
+	}
+
+	if s.step > 0 {
+		start, end := 0, n
+		if s.start != nil {
+			start = clamp(normalize(*s.start), 0, n)
+		}
+
+		if s.end != nil {
+			end = clamp(normalize(*s.end), 0, n)
+		}
+
+		for i := start; i < end; i += s.step {
+			out = append(out, rv.Index(i).Interface())
+		}
+
+		return out
+	}
+
+	start, end := n-1, -1
+	if s.start != nil {
+		start = clamp(normalize(*s.start), -1, n-1)
+	}
+
+	if s.end != nil {
+		end = clamp(normalize(*s.end), -1, n-1)
+	}
+
+	for i := start; i > end; i += s.step {
+		out = append(out, rv.Index(i).Interface())
+	}
+
+	return out
+}
+
+func clamp(i, min, max int) int {
+	if i < min {
+		return min
+	}
+
+	if i > max {
+		return max
+	}
+
+	return i
+}
+
+type filterSelector struct {
+	expr expr
+}
+
+func (s filterSelector) selectFrom(root, v interface{}, out []interface{}) []interface{} {
+	for _, child := range children(v) {
+		if s.expr.eval(root, child) {
+			out = append(out, child)
+		}
+	}
+
+	return out
+}
+
+// children returns the values of a map in the order of their sorted keys or
+// the elements of a slice. Returns nil for all other values.
+func children(v interface{}) []interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = v[key]
+		}
+
+		return values
+	case []interface{}:
+		return v
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			values[i] = rv.MapIndex(key).Interface()
+		}
+
+		return values
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, rv.Len())
+		for i := range values {
+			values[i] = rv.Index(i).Interface()
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
+
+func lookupKey(v interface{}, key string) (interface{}, bool) {
+	if m, ok := v.(map[string]interface{}); ok {
+		value, ok := m[key]
+		return value, ok
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+
+	value := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+	if !value.IsValid() {
+		return nil, false
+	}
+
+	return value.Interface(), true
+}
+
+func sliceValue(v interface{}) (reflect.Value, bool) {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv, true
+	default:
+		return rv, false
+	}
+}
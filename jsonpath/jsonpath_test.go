@@ -0,0 +1,237 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const storeJSON = `{
+  "store": {
+    "book": [
+      {"category": "reference", "author": "Nigel Rees", "title": "Sayings of the Century", "price": 8.95},
+      {"category": "fiction", "author": "Evelyn Waugh", "title": "Sword of Honour", "price": 12.99},
+      {"category": "fiction", "author": "Herman Melville", "title": "Moby Dick", "isbn": "0-553-21311-3", "price": 8.99},
+      {"category": "fiction", "author": "J. R. R. Tolkien", "title": "The Lord of the Rings", "isbn": "0-395-19395-8", "price": 22.99}
+    ],
+    "bicycle": {"color": "red", "price": 19.95}
+  },
+  "expensive": 10
+}`
+
+func loadStore(t *testing.T) interface{} {
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(storeJSON), &v))
+	return v
+}
+
+func TestPath_Get(t *testing.T) {
+	store := loadStore(t)
+
+	tests := []struct {
+		expr     string
+		want     []interface{}
+		definite bool
+	}{
+		{
+			expr:     "$",
+			want:     []interface{}{store},
+			definite: true,
+		},
+		{
+			expr:     "$.store.bicycle.color",
+			want:     []interface{}{"red"},
+			definite: true,
+		},
+		{
+			expr:     "store.bicycle['color']",
+			want:     []interface{}{"red"},
+			definite: true,
+		},
+		{
+			expr:     `$["store"]["bicycle"]["price"]`,
+			want:     []interface{}{19.95},
+			definite: true,
+		},
+		{
+			expr:     "$.store.book[-1].author",
+			want:     []interface{}{"J. R. R. Tolkien"},
+			definite: true,
+		},
+		{
+			expr:     "$.store.nonexistent",
+			want:     []interface{}{},
+			definite: true,
+		},
+		{
+			expr: "$.store.book[*].author",
+			want: []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"},
+		},
+		{
+			expr: "$..author",
+			want: []interface{}{"Nigel Rees", "Evelyn Waugh", "Herman Melville", "J. R. R. Tolkien"},
+		},
+		{
+			expr: "$.store.*.color",
+			want: []interface{}{"red"},
+		},
+		{
+			expr: "$.store..price",
+			want: []interface{}{19.95, 8.95, 12.99, 8.99, 22.99},
+		},
+		{
+			expr: "$..book[2].title",
+			want: []interface{}{"Moby Dick"},
+		},
+		{
+			expr: "$..book[0,1].title",
+			want: []interface{}{"Sayings of the Century", "Sword of Honour"},
+		},
+		{
+			expr: "$..book[:2].title",
+			want: []interface{}{"Sayings of the Century", "Sword of Honour"},
+		},
+		{
+			expr: "$..book[-2:].title",
+			want: []interface{}{"Moby Dick", "The Lord of the Rings"},
+		},
+		{
+			expr: "$..book[::-2].title",
+			want: []interface{}{"The Lord of the Rings", "Sword of Honour"},
+		},
+		{
+			expr: "$.store.bicycle['color','price']",
+			want: []interface{}{"red", 19.95},
+		},
+		{
+			expr: "$..book[?(@.isbn)].title",
+			want: []interface{}{"Moby Dick", "The Lord of the Rings"},
+		},
+		{
+			expr: "$..book[?(!@.isbn)].title",
+			want: []interface{}{"Sayings of the Century", "Sword of Honour"},
+		},
+		{
+			expr: "$..book[?(@.price < $.expensive)].title",
+			want: []interface{}{"Sayings of the Century", "Moby Dick"},
+		},
+		{
+			expr: "$..book[?(@.category == 'fiction' && @.price >= 12.99)].title",
+			want: []interface{}{"Sword of Honour", "The Lord of the Rings"},
+		},
+		{
+			expr: "$..book[?(@.price > 20 || (@.category != 'fiction'))].title",
+			want: []interface{}{"Sayings of the Century", "The Lord of the Rings"},
+		},
+		{
+			expr: "$..book[?(@.author =~ /^J\\. R/)].title",
+			want: []interface{}{"The Lord of the Rings"},
+		},
+		{
+			expr: "$..book[?(@.title =~ 'Sword')].price",
+			want: []interface{}{12.99},
+		},
+		{
+			expr: "$..book[?(@.missing == null)].price",
+			want: []interface{}{},
+		},
+		{
+			expr: "$.store.book[?(@.price == 8.95)].author",
+			want: []interface{}{"Nigel Rees"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			p, err := Parse(test.expr)
+			require.NoError(t, err)
+			require.Equal(t, test.definite, p.Definite())
+			require.Equal(t, test.want, p.Get(store))
+		})
+	}
+}
+
+func TestPath_Get_reflection(t *testing.T) {
+	v := map[string][]int{"a": {1, 2, 3}}
+
+	got, err := Get(v, "$.a[?(@ > 1)]")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{2, 3}, got)
+}
+
+func TestParse_errors(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{
+			expr: "$.",
+			err:  `invalid jsonpath expression "$.": expected name or wildcard at end of input`,
+		},
+		{
+			expr: "$[",
+			err:  `invalid jsonpath expression "$[": expected selector at end of input`,
+		},
+		{
+			expr: "$[0",
+			err:  `invalid jsonpath expression "$[0": expected ']' at end of input`,
+		},
+		{
+			expr: "$['foo",
+			err:  `invalid jsonpath expression "$['foo": unterminated string at end of input`,
+		},
+		{
+			expr: "$[::0]",
+			err:  `invalid jsonpath expression "$[::0]": slice step must not be zero at position 5`,
+		},
+		{
+			expr: "$[?(@.a == )]",
+			err:  `invalid jsonpath expression "$[?(@.a == )]": expected operand at position 11`,
+		},
+		{
+			expr: "$[?(@.a =~ 1)]",
+			err:  `invalid jsonpath expression "$[?(@.a =~ 1)]": right-hand side of =~ must be a regular expression or string at position 12`,
+		},
+		{
+			expr: "$.foo bar",
+			err:  `invalid jsonpath expression "$.foo bar": unexpected character 'b' at position 6`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			_, err := Parse(test.expr)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestFilter_Match(t *testing.T) {
+	v := map[string]interface{}{"name": "foo", "count": 2, "tags": []interface{}{"a"}}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{expr: "@.count > 1", want: true},
+		{expr: "@.count > 1 && @.name == 'bar'", want: false},
+		{expr: "$.name =~ /^f/ || @.missing", want: true},
+		{expr: "!@.tags", want: false},
+		{expr: "(@.count == 2)", want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.expr, func(t *testing.T) {
+			require.Equal(t, test.want, MustParseFilter(test.expr).Match(v))
+		})
+	}
+}
+
+func TestParseFilter_errors(t *testing.T) {
+	_, err := ParseFilter("@.a == ")
+	require.EqualError(t, err, `invalid filter expression "@.a == ": expected operand at end of input`)
+
+	_, err = ParseFilter("@.a == 1)")
+	require.EqualError(t, err, `invalid filter expression "@.a == 1)": unexpected character ')' at position 8`)
+}
@@ -0,0 +1,583 @@
+package jsonpath
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type parser struct {
+	input string
+	pos   int
+}
+
+// parseQuery parses a complete JSONPath query. The leading `$` is optional.
+func (p *parser) parseQuery() ([]segment, error) {
+	var segments []segment
+
+	p.skipSpaces()
+
+	if !p.consume('$') && isNameStart(p.peek()) {
+		// Allow omission of the root identifier, e.g. `foo.bar` instead of
+		// `$.foo.bar`.
+		segments = append(segments, segment{selectors: []selector{nameSelector(p.parseName())}})
+	}
+
+	rest, err := p.parseSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+
+	if !p.eof() {
+		return nil, p.errorf("unexpected character %q", p.peek())
+	}
+
+	return append(segments, rest...), nil
+}
+
+// parseSegments parses segments until it encounters a character that cannot
+// start a segment.
+func (p *parser) parseSegments() ([]segment, error) {
+	var segments []segment
+
+	for {
+		var (
+			seg segment
+			err error
+		)
+
+		switch {
+		case strings.HasPrefix(p.input[p.pos:], ".."):
+			p.pos += 2
+			seg, err = p.parseDotSegment(true)
+		case p.peek() == '.':
+			p.pos++
+			seg, err = p.parseDotSegment(false)
+		case p.peek() == '[':
+			seg, err = p.parseBracketSegment(false)
+		default:
+			return segments, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, seg)
+	}
+}
+
+func (p *parser) parseDotSegment(descendant bool) (segment, error) {
+	switch {
+	case descendant && p.peek() == '[':
+		return p.parseBracketSegment(true)
+	case p.consume('*'):
+		return segment{descendant: descendant, selectors: []selector{wildcardSelector{}}}, nil
+	}
+
+	name := p.parseName()
+	if name == "" {
+		return segment{}, p.errorf("expected name or wildcard")
+	}
+
+	return segment{descendant: descendant, selectors: []selector{nameSelector(name)}}, nil
+}
+
+func (p *parser) parseBracketSegment(descendant bool) (segment, error) {
+	seg := segment{descendant: descendant}
+
+	p.pos++ // [
+	p.skipSpaces()
+
+	if p.consume('?') {
+		e, err := p.parseOr()
+		if err != nil {
+			return segment{}, err
+		}
+
+		seg.selectors = append(seg.selectors, filterSelector{expr: e})
+
+		return seg, p.expect(']')
+	}
+
+	for {
+		p.skipSpaces()
+
+		sel, err := p.parseBracketSelector()
+		if err != nil {
+			return segment{}, err
+		}
+
+		seg.selectors = append(seg.selectors, sel)
+
+		p.skipSpaces()
+
+		if !p.consume(',') {
+			return seg, p.expect(']')
+		}
+	}
+}
+
+func (p *parser) parseBracketSelector() (selector, error) {
+	c := p.peek()
+
+	switch {
+	case c == '*':
+		p.pos++
+		return wildcardSelector{}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		return nameSelector(s), nil
+	case c == '-' || c == ':' || isDigit(c):
+		return p.parseIndexOrSlice()
+	default:
+		return nil, p.errorf("expected selector")
+	}
+}
+
+func (p *parser) parseIndexOrSlice() (selector, error) {
+	start, err := p.parseInt()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+
+	if !p.consume(':') {
+		if start == nil {
+			return nil, p.errorf("expected index")
+		}
+
+		return indexSelector(*start), nil
+	}
+
+	p.skipSpaces()
+
+	end, err := p.parseInt()
+	if err != nil {
+		return nil, err
+	}
+
+	sel := sliceSelector{start: start, end: end, step: 1}
+
+	p.skipSpaces()
+
+	if p.consume(':') {
+		p.skipSpaces()
+
+		step, err := p.parseInt()
+		if err != nil {
+			return nil, err
+		}
+
+		if step != nil {
+			if *step == 0 {
+				return nil, p.errorf("slice step must not be zero")
+			}
+
+			sel.step = *step
+		}
+	}
+
+	return sel, nil
+}
+
+// parseInt parses an optional integer. Returns nil if there is no integer at
+// the current position.
+func (p *parser) parseInt() (*int, error) {
+	start := p.pos
+
+	p.consume('-')
+
+	for isDigit(p.peek()) {
+		p.pos++
+	}
+
+	if p.pos == start {
+		return nil, nil
+	}
+
+	i, err := strconv.Atoi(p.input[start:p.pos])
+	if err != nil {
+		return nil, p.errorf("invalid integer %q", p.input[start:p.pos])
+	}
+
+	return &i, nil
+}
+
+// parseName parses a member name consisting of letters, digits, underscores
+// and dashes.
+func (p *parser) parseName() string {
+	start := p.pos
+
+	for !p.eof() {
+		r, size := utf8.DecodeRuneInString(p.input[p.pos:])
+		if !isNameRune(r) {
+			break
+		}
+
+		p.pos += size
+	}
+
+	return p.input[start:p.pos]
+}
+
+// parseString parses a single or double quoted string.
+func (p *parser) parseString() (string, error) {
+	quote := p.input[p.pos]
+	p.pos++
+
+	var sb strings.Builder
+
+	for !p.eof() {
+		c := p.input[p.pos]
+		p.pos++
+
+		switch c {
+		case quote:
+			return sb.String(), nil
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated string")
+			}
+
+			esc := p.input[p.pos]
+			p.pos++
+
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'u':
+				if p.pos+4 > len(p.input) {
+					return "", p.errorf("invalid unicode escape")
+				}
+
+				r, err := strconv.ParseUint(p.input[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", p.errorf("invalid unicode escape")
+				}
+
+				p.pos += 4
+				sb.WriteRune(rune(r))
+			default:
+				sb.WriteByte(esc)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+// parseFilter parses a complete filter expression.
+func (p *parser) parseFilter() (expr, error) {
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+
+	if !p.eof() {
+		return nil, p.errorf("unexpected character %q", p.peek())
+	}
+
+	return e, nil
+}
+
+// parseOr parses a filter expression.
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpaces()
+
+		if !p.consumeString("||") {
+			return left, nil
+		}
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		left = orExpr{left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipSpaces()
+
+		if !p.consumeString("&&") {
+			return left, nil
+		}
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		left = andExpr{left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	p.skipSpaces()
+
+	if p.peek() == '!' && !strings.HasPrefix(p.input[p.pos:], "!=") {
+		p.pos++
+
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		return notExpr{e}, nil
+	}
+
+	if p.consume('(') {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		p.skipSpaces()
+
+		return e, p.expect(')')
+	}
+
+	return p.parseComparison()
+}
+
+var comparisonOperators = []string{"==", "!=", "<=", ">=", "=~", "<", ">"}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseOperand(false)
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpaces()
+
+	var op string
+
+	for _, candidate := range comparisonOperators {
+		if p.consumeString(candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	if op == "" {
+		return testExpr{left}, nil
+	}
+
+	p.skipSpaces()
+
+	right, err := p.parseOperand(op == "=~")
+	if err != nil {
+		return nil, err
+	}
+
+	if op != "=~" {
+		return comparisonExpr{op: op, left: left, right: right}, nil
+	}
+
+	switch r := right.(type) {
+	case regexpOperand:
+		return matchExpr{left: left, re: r.re}, nil
+	case literalOperand:
+		s, ok := r.v.(string)
+		if !ok {
+			return nil, p.errorf("right-hand side of =~ must be a regular expression or string")
+		}
+
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, p.errorf("invalid regular expression: %v", err)
+		}
+
+		return matchExpr{left: left, re: re}, nil
+	default:
+		return nil, p.errorf("right-hand side of =~ must be a regular expression or string")
+	}
+}
+
+func (p *parser) parseOperand(allowRegexp bool) (operand, error) {
+	c := p.peek()
+
+	switch {
+	case c == '@' || c == '$':
+		p.pos++
+
+		segments, err := p.parseSegments()
+		if err != nil {
+			return nil, err
+		}
+
+		return pathOperand{absolute: c == '$', segments: segments}, nil
+	case c == '\'' || c == '"':
+		s, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		return literalOperand{s}, nil
+	case c == '-' || isDigit(c):
+		return p.parseNumber()
+	case c == '/' && allowRegexp:
+		return p.parseRegexp()
+	case p.consumeString("true"):
+		return literalOperand{true}, nil
+	case p.consumeString("false"):
+		return literalOperand{false}, nil
+	case p.consumeString("null"):
+		return literalOperand{nil}, nil
+	default:
+		return nil, p.errorf("expected operand")
+	}
+}
+
+func (p *parser) parseNumber() (operand, error) {
+	start := p.pos
+
+	p.consume('-')
+
+	for !p.eof() {
+		c := p.peek()
+		if !isDigit(c) && c != '.' && c != 'e' && c != 'E' && c != '+' && c != '-' {
+			break
+		}
+
+		p.pos++
+	}
+
+	f, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+	if err != nil {
+		return nil, p.errorf("invalid number %q", p.input[start:p.pos])
+	}
+
+	return literalOperand{f}, nil
+}
+
+func (p *parser) parseRegexp() (operand, error) {
+	p.pos++ // opening slash
+
+	var sb strings.Builder
+
+	for !p.eof() {
+		c := p.input[p.pos]
+		p.pos++
+
+		switch {
+		case c == '/':
+			re, err := regexp.Compile(sb.String())
+			if err != nil {
+				return nil, p.errorf("invalid regular expression: %v", err)
+			}
+
+			return regexpOperand{re}, nil
+		case c == '\\' && p.peek() == '/':
+			sb.WriteByte('/')
+			p.pos++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return nil, p.errorf("unterminated regular expression")
+}
+
+func (p *parser) skipSpaces() {
+	for !p.eof() && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+
+	return p.input[p.pos]
+}
+
+func (p *parser) consume(c byte) bool {
+	if p.peek() != c {
+		return false
+	}
+
+	p.pos++
+	return true
+}
+
+func (p *parser) consumeString(s string) bool {
+	if !strings.HasPrefix(p.input[p.pos:], s) {
+		return false
+	}
+
+	p.pos += len(s)
+	return true
+}
+
+func (p *parser) expect(c byte) error {
+	if !p.consume(c) {
+		return p.errorf("expected %q", c)
+	}
+
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+
+	if p.eof() {
+		return errors.New(msg + " at end of input")
+	}
+
+	return fmt.Errorf("%s at position %d", msg, p.pos)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= utf8.RuneSelf
+}
+
+func isNameRune(r rune) bool {
+	return r == '_' || r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
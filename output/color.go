@@ -0,0 +1,345 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/mgutz/ansi"
+)
+
+// ColorMode controls whether formatters that support it produce colorized
+// output.
+type ColorMode int
+
+const (
+	// ColorAuto enables colors if the output is written to a terminal and
+	// the NO_COLOR environment variable is not set. Since FormatBytes and
+	// FormatString do not know about the destination of the output, they
+	// treat ColorAuto like ColorNever.
+	ColorAuto ColorMode = iota
+	// ColorAlways unconditionally enables colors.
+	ColorAlways
+	// ColorNever disables colors.
+	ColorNever
+)
+
+var colorModeNames = []string{"auto", "always", "never"}
+
+// ParseColorMode parses s into a ColorMode. Valid values are "auto",
+// "always" and "never".
+func ParseColorMode(s string) (ColorMode, error) {
+	for i, name := range colorModeNames {
+		if s == name {
+			return ColorMode(i), nil
+		}
+	}
+
+	return ColorAuto, fmt.Errorf(`invalid color mode %q, possible values: "%s"`, s, strings.Join(colorModeNames, `", "`))
+}
+
+// String implements fmt.Stringer.
+func (m ColorMode) String() string {
+	if m < 0 || int(m) >= len(colorModeNames) {
+		return fmt.Sprintf("ColorMode(%d)", int(m))
+	}
+
+	return colorModeNames[m]
+}
+
+// Set implements the pflag.Value interface.
+func (m *ColorMode) Set(s string) error {
+	mode, err := ParseColorMode(s)
+	if err != nil {
+		return err
+	}
+
+	*m = mode
+	return nil
+}
+
+// Type implements the pflag.Value interface.
+func (m *ColorMode) Type() string {
+	return "string"
+}
+
+// resolveColor resolves ColorAuto in config based on w. Returns a copy of
+// config with the Color field set to either ColorAlways or ColorNever if
+// config.Color is ColorAuto, config itself otherwise.
+func resolveColor(w io.Writer, config *Config) *Config {
+	if config.Color != ColorAuto {
+		return config
+	}
+
+	c := *config
+	c.Color = ColorNever
+
+	if colorEnabled(w) {
+		c.Color = ColorAlways
+	}
+
+	return &c
+}
+
+// colorEnabled returns true if output written to w should be colorized in
+// ColorAuto mode.
+func colorEnabled(w io.Writer) bool {
+	return !noColor() && isTerminal(w)
+}
+
+// isTerminal returns true if w is a file descriptor that refers to a
+// terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	if !ok {
+		return false
+	}
+
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+var (
+	keyColor    = ansi.ColorFunc("blue+b")
+	stringColor = ansi.ColorFunc("green")
+	numberColor = ansi.ColorFunc("cyan")
+	boolColor   = ansi.ColorFunc("yellow")
+	nullColor   = ansi.ColorFunc("black+h")
+)
+
+// colorizeJSON adds ANSI colors to the JSON document in buf. Buf must
+// contain valid JSON.
+func colorizeJSON(buf []byte) []byte {
+	var out bytes.Buffer
+
+	out.Grow(len(buf) * 2)
+
+	for i := 0; i < len(buf); {
+		c := buf[i]
+
+		switch {
+		case c == '"':
+			end := scanJSONString(buf, i)
+			token := string(buf[i:end])
+
+			if isJSONKey(buf, end) {
+				out.WriteString(keyColor(token))
+			} else {
+				out.WriteString(stringColor(token))
+			}
+
+			i = end
+		case c == '-' || c >= '0' && c <= '9':
+			end := i + 1
+			for end < len(buf) && strings.IndexByte("0123456789.eE+-", buf[end]) != -1 {
+				end++
+			}
+
+			out.WriteString(numberColor(string(buf[i:end])))
+			i = end
+		case bytes.HasPrefix(buf[i:], []byte("true")):
+			out.WriteString(boolColor("true"))
+			i += 4
+		case bytes.HasPrefix(buf[i:], []byte("false")):
+			out.WriteString(boolColor("false"))
+			i += 5
+		case bytes.HasPrefix(buf[i:], []byte("null")):
+			out.WriteString(nullColor("null"))
+			i += 4
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	return out.Bytes()
+}
+
+// scanJSONString returns the index after the closing quote of the JSON string
+// starting at buf[start].
+func scanJSONString(buf []byte, start int) int {
+	for i := start + 1; i < len(buf); i++ {
+		switch buf[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+
+	return len(buf)
+}
+
+// isJSONKey returns true if the next non-whitespace character at or after
+// buf[i] is a colon.
+func isJSONKey(buf []byte, i int) bool {
+	for ; i < len(buf); i++ {
+		switch buf[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		case ':':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+var (
+	yamlNumberRegexp = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	yamlBoolRegexp   = regexp.MustCompile(`^(true|false|True|False|TRUE|FALSE)$`)
+	yamlNullRegexp   = regexp.MustCompile(`^(null|Null|NULL|~)$`)
+)
+
+// colorizeYAML adds ANSI colors to the YAML document in buf. It expects the
+// block style produced by the yaml formatter.
+func colorizeYAML(buf []byte) []byte {
+	var out bytes.Buffer
+
+	out.Grow(len(buf) * 2)
+
+	// blockIndent is the indentation of the key that started a block
+	// scalar or -1 if the current line is not part of a block scalar.
+	blockIndent := -1
+
+	lines := strings.SplitAfter(string(buf), "\n")
+
+	for _, line := range lines {
+		content := strings.TrimRight(line, "\n")
+		newline := line[len(content):]
+		indent := len(content) - len(strings.TrimLeft(content, " "))
+
+		if blockIndent >= 0 {
+			if strings.TrimSpace(content) == "" || indent > blockIndent {
+				out.WriteString(colorizeNonEmpty(content, stringColor))
+				out.WriteString(newline)
+				continue
+			}
+
+			blockIndent = -1
+		}
+
+		if content == "---" || content == "..." || strings.TrimSpace(content) == "" {
+			out.WriteString(line)
+			continue
+		}
+
+		out.WriteString(content[:indent])
+		rest := content[indent:]
+
+		// Sequence item markers, possibly nested like `- - foo`.
+		for strings.HasPrefix(rest, "- ") || rest == "-" {
+			n := len(rest)
+			if n > 2 {
+				n = 2
+			}
+
+			out.WriteString(rest[:n])
+			rest = rest[n:]
+			indent += n
+		}
+
+		if key, value, ok := splitYAMLKey(rest); ok {
+			out.WriteString(keyColor(key))
+			out.WriteByte(':')
+
+			if value == "" {
+				out.WriteString(newline)
+				continue
+			}
+
+			out.WriteByte(' ')
+			rest = value
+
+			if isYAMLBlockIndicator(value) {
+				out.WriteString(value)
+				out.WriteString(newline)
+				blockIndent = indent
+				continue
+			}
+		}
+
+		out.WriteString(colorizeYAMLScalar(rest))
+		out.WriteString(newline)
+	}
+
+	return out.Bytes()
+}
+
+// splitYAMLKey splits s of the form `key: value` or `key:` into key and
+// value. Returns false if s does not start with a mapping key.
+func splitYAMLKey(s string) (key, value string, ok bool) {
+	var end int
+
+	if s != "" && (s[0] == '"' || s[0] == '\'') {
+		end = closingQuote(s)
+		if end == -1 || end >= len(s) || s[end] != ':' {
+			return "", "", false
+		}
+	} else {
+		end = strings.Index(s, ": ")
+		if end == -1 {
+			if !strings.HasSuffix(s, ":") {
+				return "", "", false
+			}
+
+			end = len(s) - 1
+		}
+	}
+
+	if end+1 < len(s) && s[end+1] != ' ' {
+		return "", "", false
+	}
+
+	return s[:end], strings.TrimPrefix(s[end+1:], " "), true
+}
+
+// closingQuote returns the index after the closing quote of the quoted string
+// at the start of s or -1 if the quote is not closed.
+func closingQuote(s string) int {
+	quote := s[0]
+
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote == '\'' && s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+func isYAMLBlockIndicator(s string) bool {
+	return s != "" && (s[0] == '|' || s[0] == '>')
+}
+
+func colorizeYAMLScalar(s string) string {
+	switch {
+	case s == "" || s == "[]" || s == "{}":
+		return s
+	case yamlNullRegexp.MatchString(s):
+		return nullColor(s)
+	case yamlBoolRegexp.MatchString(s):
+		return boolColor(s)
+	case yamlNumberRegexp.MatchString(s):
+		return numberColor(s)
+	default:
+		return stringColor(s)
+	}
+}
+
+func colorizeNonEmpty(s string, color func(string) string) string {
+	if s == "" {
+		return s
+	}
+
+	return color(s)
+}
@@ -0,0 +1,110 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseColorMode(t *testing.T) {
+	mode, err := ParseColorMode("always")
+	require.NoError(t, err)
+	require.Equal(t, ColorAlways, mode)
+	require.Equal(t, "always", mode.String())
+
+	_, err = ParseColorMode("sometimes")
+	require.EqualError(t, err, `invalid color mode "sometimes", possible values: "auto", "always", "never"`)
+
+	var m ColorMode
+	require.NoError(t, m.Set("never"))
+	require.Equal(t, ColorNever, m)
+}
+
+func TestFormat_color(t *testing.T) {
+	v := map[string]interface{}{
+		"str":  "foo",
+		"num":  -1.5e3,
+		"bool": true,
+		"null": nil,
+		"list": []interface{}{"a", 1},
+		"text": "line1\nline2\n",
+	}
+
+	t.Run("json", func(t *testing.T) {
+		got, err := FormatString(v, &Config{Format: "json", Color: ColorAlways})
+		require.NoError(t, err)
+
+		want := "{\n" +
+			`  ` + keyColor(`"bool"`) + `: ` + boolColor("true") + ",\n" +
+			`  ` + keyColor(`"list"`) + `: [` + "\n" +
+			`    ` + stringColor(`"a"`) + ",\n" +
+			`    ` + numberColor("1") + "\n" +
+			`  ],` + "\n" +
+			`  ` + keyColor(`"null"`) + `: ` + nullColor("null") + ",\n" +
+			`  ` + keyColor(`"num"`) + `: ` + numberColor("-1500") + ",\n" +
+			`  ` + keyColor(`"str"`) + `: ` + stringColor(`"foo"`) + ",\n" +
+			`  ` + keyColor(`"text"`) + `: ` + stringColor(`"line1\nline2\n"`) + "\n" +
+			"}"
+
+		require.Equal(t, want, got)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		got, err := FormatString(v, &Config{Format: "yaml", Color: ColorAlways})
+		require.NoError(t, err)
+
+		want := keyColor("bool") + ": " + boolColor("true") + "\n" +
+			keyColor("list") + ":\n" +
+			"- " + stringColor("a") + "\n" +
+			"- " + numberColor("1") + "\n" +
+			keyColor(`"null"`) + ": " + nullColor("null") + "\n" +
+			keyColor("num") + ": " + numberColor("-1500") + "\n" +
+			keyColor("str") + ": " + stringColor("foo") + "\n" +
+			keyColor("text") + ": |\n" +
+			stringColor("  line1") + "\n" +
+			stringColor("  line2") + "\n"
+
+		require.Equal(t, want, got)
+	})
+
+	t.Run("yaml nested sequences and quoted keys", func(t *testing.T) {
+		got, err := FormatString([]interface{}{
+			map[string]interface{}{"a b": map[string]interface{}{"c": "true"}},
+			[]interface{}{"x"},
+		}, &Config{Format: "yaml", Color: ColorAlways})
+		require.NoError(t, err)
+
+		want := "- " + keyColor("a b") + ":\n" +
+			"    " + keyColor("c") + ": " + stringColor(`"true"`) + "\n" +
+			"- - " + stringColor("x") + "\n"
+
+		require.Equal(t, want, got)
+	})
+
+	t.Run("auto disables colors for non-terminals", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, Format(&buf, v["list"], &Config{Format: "json"}))
+		require.Equal(t, "[\n  \"a\",\n  1\n]", buf.String())
+	})
+
+	t.Run("NO_COLOR disables auto colors", func(t *testing.T) {
+		defer restoreEnv("NO_COLOR")()
+		os.Setenv("NO_COLOR", "1")
+
+		config := resolveColor(os.Stdout, &Config{Format: "json"})
+		require.Equal(t, ColorNever, config.Color)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		enc, err := NewEncoder(&buf, &Config{Format: "json-lines", Color: ColorAlways})
+		require.NoError(t, err)
+		require.NoError(t, enc.Encode(map[string]interface{}{"a": 1}))
+		require.NoError(t, enc.Close())
+		require.Equal(t, "{"+keyColor(`"a"`)+":"+numberColor("1")+"}\n", buf.String())
+	})
+}
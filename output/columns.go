@@ -0,0 +1,177 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mitchellh/pointerstructure"
+)
+
+// noneValue is rendered in custom columns if the JSON pointer of a column
+// does not match any value.
+const noneValue = "<none>"
+
+// customColumn is a column of the custom-columns format.
+type customColumn struct {
+	header  string
+	pointer *pointerstructure.Pointer
+}
+
+func formatCustomColumns(v interface{}, config *Config) ([]byte, error) {
+	if config.Template == "" {
+		return nil, errors.New("custom-columns format requires a column spec, e.g. custom-columns=NAME:/metadata/name")
+	}
+
+	columns, err := parseCustomColumns(config.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	return formatColumns(v, columns)
+}
+
+func formatCustomColumnsFile(v interface{}, config *Config) ([]byte, error) {
+	if config.Template == "" {
+		return nil, errors.New("custom-columns-file format requires a file path, e.g. custom-columns-file=columns.txt")
+	}
+
+	buf, err := os.ReadFile(config.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := parseCustomColumnsFile(buf)
+	if err != nil {
+		return nil, fmt.Errorf("invalid custom columns file %q: %w", config.Template, err)
+	}
+
+	return formatColumns(v, columns)
+}
+
+// parseCustomColumns parses a spec of the form
+//
+//   HEADER1:/json/pointer1,HEADER2:/json/pointer2
+//
+// into custom columns.
+func parseCustomColumns(spec string) ([]customColumn, error) {
+	parts := strings.Split(spec, ",")
+	columns := make([]customColumn, len(parts))
+
+	for i, part := range parts {
+		fields := strings.SplitN(part, ":", 2)
+		if len(fields) != 2 || fields[0] == "" {
+			return nil, fmt.Errorf("invalid custom column %q, expected <header>:<json-pointer>", part)
+		}
+
+		column, err := newCustomColumn(fields[0], fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		columns[i] = column
+	}
+
+	return columns, nil
+}
+
+// parseCustomColumnsFile parses custom columns from the contents of a file.
+// The first non-empty line must contain whitespace separated headers, the
+// second one the corresponding JSON pointers:
+//
+//   NAME            AGE
+//   /metadata/name  /age
+func parseCustomColumnsFile(buf []byte) ([]customColumn, error) {
+	var lines [][]string
+
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			lines = append(lines, fields)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("expected exactly 2 non-empty lines, got %d", len(lines))
+	}
+
+	headers, pointers := lines[0], lines[1]
+	if len(headers) != len(pointers) {
+		return nil, fmt.Errorf("number of headers (%d) does not match number of json pointers (%d)", len(headers), len(pointers))
+	}
+
+	columns := make([]customColumn, len(headers))
+
+	for i, header := range headers {
+		column, err := newCustomColumn(header, pointers[i])
+		if err != nil {
+			return nil, err
+		}
+
+		columns[i] = column
+	}
+
+	return columns, nil
+}
+
+func newCustomColumn(header, pointer string) (customColumn, error) {
+	p, err := pointerstructure.Parse(pointer)
+	if err != nil {
+		return customColumn{}, fmt.Errorf("invalid json pointer for column %q: %w", header, err)
+	}
+
+	return customColumn{header: header, pointer: p}, nil
+}
+
+// formatColumns renders v as a table with the given columns. If v is a slice
+// each item is rendered as a row, otherwise v is rendered as a single row.
+func formatColumns(v interface{}, columns []customColumn) ([]byte, error) {
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := nv.([]interface{})
+	if !ok {
+		items = []interface{}{nv}
+	}
+
+	t := &tabular{
+		keys: make([]string, len(columns)),
+		rows: make([][]interface{}, len(items)),
+	}
+
+	for i, column := range columns {
+		t.keys[i] = column.header
+	}
+
+	for i, item := range items {
+		row := make([]interface{}, len(columns))
+
+		for j, column := range columns {
+			value, err := column.pointer.Get(item)
+			if err != nil || value == nil {
+				// Missing values are not an error, they are rendered as
+				// placeholder instead.
+				value = noneValue
+			}
+
+			row[j] = value
+		}
+
+		t.rows[i] = row
+	}
+
+	var buf bytes.Buffer
+
+	writeTable(&buf, t.keys, stringRows(t.rows), numericColumns(t))
+
+	return buf.Bytes(), nil
+}
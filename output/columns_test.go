@@ -0,0 +1,100 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatCustomColumns(t *testing.T) {
+	items := []map[string]interface{}{
+		{"metadata": map[string]interface{}{"name": "foo"}, "age": 42},
+		{"metadata": map[string]interface{}{"name": "barbaz"}, "age": 7},
+		{"metadata": map[string]interface{}{}},
+	}
+
+	tests := []formatTestCase{
+		{
+			name: "requires spec",
+			cfg:  Config{Format: "custom-columns"},
+			err:  errors.New("custom-columns format requires a column spec, e.g. custom-columns=NAME:/metadata/name"),
+		},
+		{
+			name: "invalid spec",
+			cfg:  Config{Format: "custom-columns=NAME"},
+			err:  errors.New(`invalid custom column "NAME", expected <header>:<json-pointer>`),
+		},
+		{
+			name: "invalid json pointer",
+			cfg:  Config{Format: "custom-columns=NAME:metadata"},
+			err:  errors.New(`invalid json pointer for column "NAME": parse Go pointer "metadata": first char must be '/'`),
+		},
+		{
+			name: "slice",
+			cfg:  Config{Format: "custom-columns=NAME:/metadata/name,AGE:/age"},
+			v:    items,
+			want: `NAME     AGE
+foo      42
+barbaz   7
+<none>   <none>
+`,
+		},
+		{
+			name: "single object",
+			cfg:  Config{Format: "custom-columns=AGE:/age"},
+			v:    items[0],
+			want: "AGE\n 42\n",
+		},
+		{
+			name: "spec from template",
+			cfg:  Config{Format: "custom-columns", Template: "NAME:/metadata/name"},
+			v:    items[:2],
+			want: "NAME\nfoo\nbarbaz\n",
+		},
+		{
+			name: "struct json names",
+			cfg:  Config{Format: "custom-columns=NAME:/name,LABELS:/labels"},
+			v:    []tableTestItem{{Name: "foo", Labels: map[string]string{"a": "b"}}},
+			want: "NAME   LABELS\nfoo    {\"a\":\"b\"}\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
+
+func TestFormatCustomColumnsFile(t *testing.T) {
+	dir := t.TempDir()
+
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	valid := writeFile("valid.txt", "\nNAME      AGE\n/name     /age\n")
+	mismatch := writeFile("mismatch.txt", "NAME AGE\n/name\n")
+
+	tests := []formatTestCase{
+		{
+			name: "requires path",
+			cfg:  Config{Format: "custom-columns-file"},
+			err:  errors.New("custom-columns-file format requires a file path, e.g. custom-columns-file=columns.txt"),
+		},
+		{
+			name: "columns from file",
+			cfg:  Config{Format: "custom-columns-file=" + valid},
+			v:    []map[string]interface{}{{"name": "foo", "age": 42}},
+			want: "NAME   AGE\nfoo     42\n",
+		},
+		{
+			name: "header mismatch",
+			cfg:  Config{Format: "custom-columns-file=" + mismatch},
+			err:  errors.New(`invalid custom columns file "` + mismatch + `": number of headers (2) does not match number of json pointers (1)`),
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,295 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"sync"
+	"text/template"
+
+	"github.com/martinohmann/exp/jsonpath"
+	"github.com/mitchellh/pointerstructure"
+)
+
+// Compiled is a Config which was compiled via Compile. The formatter lookup,
+// formatter options, the JSON pointer, the query and templates are resolved
+// and parsed only once, which makes formatting many values with the same
+// config considerably cheaper. A *Compiled is safe for concurrent use.
+type Compiled struct {
+	formatter Formatter
+	selector  *selector
+	config    *Config
+	// plain and colored are used in place of config by Format if colors
+	// are configured as ColorAuto.
+	plain   *Config
+	colored *Config
+}
+
+// Compile compiles config into a *Compiled which can be used to format
+// values repeatedly. Returns an error if there is no formatter for
+// config.Format, if formatter options are invalid or if the JSON pointer or
+// query cannot be parsed. Template errors are returned on first use. Later
+// changes to config do not affect the returned *Compiled.
+func Compile(config *Config) (*Compiled, error) {
+	c, err := compile(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.config.Color == ColorAuto {
+		// The template cache is shared by the copies.
+		plain, colored := *c.config, *c.config
+		plain.Color, colored.Color = ColorNever, ColorAlways
+		c.plain, c.colored = &plain, &colored
+	}
+
+	return c, nil
+}
+
+// compile compiles config without resolving ColorAuto. Callers that format
+// only once resolve colors themselves, which avoids copying the config.
+func compile(config *Config) (*Compiled, error) {
+	f, config, err := lookupFormatter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	config.templateCache = &templateCache{}
+
+	s, err := compileSelector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Compiled{formatter: f, selector: s, config: config}, nil
+}
+
+// MustCompile is like Compile but panics if config cannot be compiled. It is
+// intended for configs which are known to be valid, e.g. in variable
+// initializations.
+func MustCompile(config *Config) *Compiled {
+	c, err := Compile(config)
+	if err != nil {
+		panic(fmt.Sprintf("output: Compile(%q): %v", config.Format, err))
+	}
+
+	return c
+}
+
+// Format formats v and writes the result to w. Returns any error that may
+// occur during formatting. On errors nothing is written to w.
+func (c *Compiled) Format(w io.Writer, v interface{}) error {
+	config := c.config
+
+	if c.plain != nil {
+		config = c.plain
+
+		if colorEnabled(w) {
+			config = c.colored
+		}
+	}
+
+	buf, err := c.format(v, config)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// FormatBytes formats v and returns the formatted bytes. Returns any error
+// that may occur during formatting.
+func (c *Compiled) FormatBytes(v interface{}) ([]byte, error) {
+	return c.format(v, c.config)
+}
+
+// FormatString formats v and returns the formatted string. Returns any error
+// that may occur during formatting.
+func (c *Compiled) FormatString(v interface{}) (string, error) {
+	buf, err := c.format(v, c.config)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func (c *Compiled) format(v interface{}, config *Config) ([]byte, error) {
+	v, err := c.selector.selectValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := c.formatter.Format(v, config)
+	if err != nil {
+		return nil, err
+	}
+
+	// Append a trailing newline if requested, but only if the formatter did
+	// not already do that for us.
+	if config.TrailingNewline && len(buf) > 0 && buf[len(buf)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+
+	return buf, nil
+}
+
+// selector selects the nested value that should be passed to the formatter
+// using a parsed JSON pointer and query and transforms it afterwards.
+type selector struct {
+	pointer   *pointerstructure.Pointer
+	path      *jsonpath.Path
+	transform *transform
+}
+
+// compileSelector parses the JSON pointer, the query and the transformation
+// stages of config. If config.Columns is empty, it defaults to the selected
+// fields so that table-like formats render them in the order they were
+// selected.
+func compileSelector(config *Config) (*selector, error) {
+	var (
+		s   selector
+		err error
+	)
+
+	if config.JSONPointer != "" {
+		s.pointer, err = pointerstructure.Parse(config.JSONPointer)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.Query != "" {
+		s.path, err = jsonpath.Parse(config.Query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.transform, err = compileTransform(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.transform != nil && len(config.Columns) == 0 {
+		config.Columns = s.transform.columns()
+	}
+
+	return &s, nil
+}
+
+// selectValue selects the nested value of v and applies the transformation
+// stages to it.
+func (s *selector) selectValue(v interface{}) (interface{}, error) {
+	v, err := s.pick(v)
+	if err != nil || s.transform == nil {
+		return v, err
+	}
+
+	return s.transform.apply(v)
+}
+
+// pick selects the nested value of v. Struct fields tagged as sensitive are
+// masked in the selected value. Sensitive fields which are traversed by the
+// JSON pointer are masked as well, so that they cannot be selected either.
+func (s *selector) pick(v interface{}) (interface{}, error) {
+	if s.pointer != nil {
+		pv, err := s.getPointer(v)
+		if err != nil {
+			return nil, err
+		}
+
+		v = pv
+	}
+
+	v, err := maskSensitive(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.path == nil {
+		return v, nil
+	}
+
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	values := s.path.Get(nv)
+
+	if !s.path.Definite() {
+		return values, nil
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("query %q did not match any value", s.path)
+	}
+
+	return values[0], nil
+}
+
+// getPointer resolves the JSON pointer against v. Values of struct fields
+// tagged as sensitive are masked when they are traversed. Maps and slices of
+// the types produced by encoding/json are traversed without reflection.
+func (s *selector) getPointer(v interface{}) (interface{}, error) {
+	cur := v
+
+	for _, part := range s.pointer.Parts {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			if elem, ok := c[part]; ok {
+				cur = elem
+				continue
+			}
+		case []interface{}:
+			if idx, err := strconv.Atoi(part); err == nil && idx >= 0 && idx < len(c) && strconv.Itoa(idx) == part {
+				cur = c[idx]
+				continue
+			}
+		}
+
+		// The value cannot be traversed without reflection or the part does
+		// not exist. Fall back to pointerstructure, which also produces the
+		// error messages.
+		return s.getPointerReflect(v)
+	}
+
+	return cur, nil
+}
+
+func (s *selector) getPointerReflect(v interface{}) (interface{}, error) {
+	p := *s.pointer
+	parent := reflect.ValueOf(v)
+	part := 0
+
+	p.Config.ValueTransformationHook = func(rv reflect.Value) reflect.Value {
+		if isSensitiveField(parent, p.Parts[part]) {
+			rv, _ = maskField(rv)
+		}
+
+		parent = rv
+		part++
+
+		return rv
+	}
+
+	return p.Get(v)
+}
+
+// templateCache parses the template of a Config at most once.
+type templateCache struct {
+	once sync.Once
+	tpl  *template.Template
+	err  error
+}
+
+func (c *templateCache) parse(config *Config) (*template.Template, error) {
+	c.once.Do(func() {
+		c.tpl, c.err = parseTemplateUncached(config)
+	})
+
+	return c.tpl, c.err
+}
@@ -0,0 +1,216 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+		err  error
+	}{
+		{
+			name: "unknown format",
+			cfg:  Config{Format: "none"},
+			err:  errors.New(`no formatter for format "none"`),
+		},
+		{
+			name: "invalid option",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"foo": true}},
+			err:  errors.New(`format "json" does not support option "foo"`),
+		},
+		{
+			name: "invalid json pointer",
+			cfg:  Config{Format: "json", JSONPointer: "foo"},
+			err:  errors.New(`parse Go pointer "foo": first char must be '/'`),
+		},
+		{
+			name: "invalid query",
+			cfg:  Config{Format: "json", Query: "$["},
+			err:  errors.New(`invalid jsonpath expression "$[": expected selector at end of input`),
+		},
+		{
+			name: "template errors are deferred",
+			cfg:  Config{Format: "gotemplate"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := Compile(&test.cfg)
+			if test.err != nil {
+				require.EqualError(t, err, test.err.Error())
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, c)
+			}
+		})
+	}
+}
+
+func TestMustCompile(t *testing.T) {
+	require.PanicsWithValue(t, `output: Compile("none"): no formatter for format "none"`, func() {
+		MustCompile(&Config{Format: "none"})
+	})
+}
+
+func TestCompiled(t *testing.T) {
+	v := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"name": "foo", "count": 1},
+			map[string]interface{}{"name": "bar", "count": 2},
+		},
+	}
+
+	t.Run("evaluates json pointer and query", func(t *testing.T) {
+		c := MustCompile(&Config{Format: "yml", JSONPointer: "/items", Query: "$[*].name"})
+
+		for i := 0; i < 2; i++ {
+			got, err := c.FormatString(v)
+			require.NoError(t, err)
+			require.Equal(t, "- foo\n- bar\n", got)
+		}
+	})
+
+	t.Run("is not affected by config changes", func(t *testing.T) {
+		config := &Config{Format: "gotemplate", Template: "{{len .items}}", TrailingNewline: true}
+		c := MustCompile(config)
+
+		config.Template = "{{.}}"
+		config.TrailingNewline = false
+
+		var buf bytes.Buffer
+
+		require.NoError(t, c.Format(&buf, v))
+		require.Equal(t, "2\n", buf.String())
+	})
+
+	t.Run("does not colorize non-terminal writers", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		require.NoError(t, MustCompile(&Config{Format: "json", JSONPointer: "/items/0/name"}).Format(&buf, v))
+		require.Equal(t, `"foo"`, buf.String())
+
+		got, err := MustCompile(&Config{Format: "json", Color: ColorAlways, JSONPointer: "/items/0/name"}).FormatString(v)
+		require.NoError(t, err)
+		require.Equal(t, stringColor(`"foo"`), got)
+	})
+
+	t.Run("parses templates once", func(t *testing.T) {
+		var opens int32
+
+		fsys := countingFS{
+			FS:    fstest.MapFS{"item.tmpl": {Data: []byte("{{.name}}")}},
+			opens: &opens,
+		}
+
+		c := MustCompile(&Config{Format: "gotemplate", Template: "@item.tmpl", TemplateFS: fsys})
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				got, err := c.FormatString(map[string]interface{}{"name": "foo"})
+				require.NoError(t, err)
+				require.Equal(t, "foo", got)
+			}()
+		}
+
+		wg.Wait()
+
+		require.Equal(t, int32(1), atomic.LoadInt32(&opens))
+	})
+
+	t.Run("template errors are returned on each use", func(t *testing.T) {
+		c := MustCompile(&Config{Format: "gotemplate"})
+
+		for i := 0; i < 2; i++ {
+			_, err := c.FormatBytes(nil)
+			require.EqualError(t, err, "template must not be empty")
+		}
+	})
+}
+
+// countingFS counts the number of opened files.
+type countingFS struct {
+	fs.FS
+	opens *int32
+}
+
+func (f countingFS) Open(name string) (fs.File, error) {
+	atomic.AddInt32(f.opens, 1)
+	return f.FS.Open(name)
+}
+
+func benchmarkValue() interface{} {
+	items := make([]interface{}, 10)
+	for i := range items {
+		items[i] = map[string]interface{}{"name": "foo", "count": i, "labels": map[string]interface{}{"app": "bar"}}
+	}
+
+	return map[string]interface{}{"items": items}
+}
+
+var benchmarkConfigs = []struct {
+	name string
+	cfg  Config
+}{
+	{
+		name: "gotemplate",
+		cfg:  Config{Format: "gotemplate", Template: `{{range .items}}{{.name}} {{.count}} {{.labels.app}}{{"\n"}}{{end}}`},
+	},
+	{
+		name: "json with pointer",
+		cfg:  Config{Format: "json", JSONPointer: "/items/3/labels"},
+	},
+	{
+		name: "json with query",
+		cfg:  Config{Format: "json", Query: "$.items[?(@.count > 5)].name"},
+	},
+}
+
+// BenchmarkFormat compares formatting via Format, which looks up the
+// formatter, resolves options and parses the JSON pointer, query and template
+// on every call, with formatting via a Compiled config.
+func BenchmarkFormat(b *testing.B) {
+	v := benchmarkValue()
+
+	for _, bc := range benchmarkConfigs {
+		b.Run(bc.name+"/uncompiled", func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if err := Format(io.Discard, v, &bc.cfg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(bc.name+"/compiled", func(b *testing.B) {
+			c := MustCompile(&bc.cfg)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := c.Format(io.Discard, v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
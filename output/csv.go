@@ -0,0 +1,43 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+func formatCSV(v interface{}, config *Config) ([]byte, error) {
+	return formatDelimited(v, config, ',')
+}
+
+func formatTSV(v interface{}, config *Config) ([]byte, error) {
+	return formatDelimited(v, config, '\t')
+}
+
+// formatDelimited renders v as delimiter separated values. The first record
+// contains the column headers. Single objects are rendered as a single record,
+// nested values are encoded as JSON.
+func formatDelimited(v interface{}, config *Config, delimiter rune) ([]byte, error) {
+	t, err := newTabular(v, config.Columns, config.Wide)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.keys) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+
+	w := csv.NewWriter(&buf)
+	w.Comma = delimiter
+
+	if err := w.Write(t.headerNames(nil)); err != nil {
+		return nil, err
+	}
+
+	if err := w.WriteAll(stringRows(t.rows)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package output
+
+import "testing"
+
+func TestFormatCSV(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "nil",
+			cfg:  Config{Format: "csv"},
+			want: "",
+		},
+		{
+			name: "slice of maps uses union of keys",
+			cfg:  Config{Format: "csv"},
+			v: []map[string]interface{}{
+				{"name": "foo", "count": 1},
+				{"name": "bar", "extra": true},
+			},
+			want: "count,name,extra\n1,foo,\n,bar,true\n",
+		},
+		{
+			name: "slice of structs",
+			cfg:  Config{Format: "csv"},
+			v: []tableTestItem{
+				{Name: "foo, bar", Age: 42, Labels: map[string]string{"a": "b"}},
+				{Name: `say "hi"`, Age: 7},
+			},
+			want: "name,age,labels\n\"foo, bar\",42,\"{\"\"a\"\":\"\"b\"\"}\"\n\"say \"\"hi\"\"\",7,\n",
+		},
+		{
+			name: "column projection",
+			cfg:  Config{Format: "csv", Columns: []string{"age", "name"}},
+			v:    []tableTestItem{{Name: "foo", Age: 42}},
+			want: "age,name\n42,foo\n",
+		},
+		{
+			name: "single object",
+			cfg:  Config{Format: "csv"},
+			v:    map[string]interface{}{"foo": "bar", "baz": []int{1, 2}},
+			want: "baz,foo\n\"[1,2]\",bar\n",
+		},
+		{
+			name: "scalars",
+			cfg:  Config{Format: "csv"},
+			v:    []interface{}{"foo", 1},
+			want: "value\nfoo\n1\n",
+		},
+		{
+			name: "tsv",
+			cfg:  Config{Format: "tsv"},
+			v: []map[string]interface{}{
+				{"name": "foo\tbar", "count": 1},
+				{"name": "baz", "nested": map[string]interface{}{"a": 1}},
+			},
+			want: "count\tname\tnested\n1\t\"foo\tbar\"\t\n\tbaz\t\"{\"\"a\"\":1}\"\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,591 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mgutz/ansi"
+)
+
+// DefaultDiffContext is the default number of unchanged lines shown around
+// changes in unified diffs.
+const DefaultDiffContext = 3
+
+var (
+	diffHeaderColor  = ansi.ColorFunc("white+b")
+	diffHunkColor    = ansi.ColorFunc("cyan")
+	diffAddedColor   = ansi.ColorFunc("green")
+	diffRemovedColor = ansi.ColorFunc("red")
+	diffChangedColor = ansi.ColorFunc("yellow")
+)
+
+// DiffConfig configures how Diff renders the differences between two values.
+type DiffConfig struct {
+	// Structural renders a list of added, removed and changed values keyed
+	// by their JSON pointer instead of a unified diff of the formatted
+	// values. Structural diffs do not depend on the configured format, so
+	// reordered object keys or differences in formatting do not show up.
+	Structural bool
+	// Context is the number of unchanged lines shown around changes in
+	// unified diffs. If zero, DefaultDiffContext is used. Negative values
+	// disable context lines.
+	Context int
+	// FromLabel and ToLabel are the names of the values shown in the header
+	// of unified diffs. Default to "before" and "after".
+	FromLabel string
+	ToLabel   string
+}
+
+// Diff renders the differences between before and after and writes them to
+// w. Both values are formatted using config, which includes the evaluation of
+// JSONPointer and Query. Colors are applied to the diff according to
+// config.Color, the formatted values themselves are not colorized. Nothing is
+// written if there are no differences. DiffConfig may be nil.
+func Diff(w io.Writer, before, after interface{}, config *Config, diffConfig *DiffConfig) error {
+	buf, err := DiffBytes(before, after, resolveColor(w, config), diffConfig)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf)
+	return err
+}
+
+// DiffBytes is like Diff but returns the rendered differences.
+func DiffBytes(before, after interface{}, config *Config, diffConfig *DiffConfig) ([]byte, error) {
+	if diffConfig == nil {
+		diffConfig = &DiffConfig{}
+	}
+
+	colored := config.Color == ColorAlways
+
+	c := *config
+	c.Color = ColorNever
+
+	compiled, err := Compile(&c)
+	if err != nil {
+		return nil, err
+	}
+
+	if diffConfig.Structural {
+		return structuralDiff(compiled, before, after, colored)
+	}
+
+	from, err := compiled.FormatBytes(before)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := compiled.FormatBytes(after)
+	if err != nil {
+		return nil, err
+	}
+
+	return unifiedDiff(from, to, diffConfig, colored), nil
+}
+
+// ChangeType is the type of a Change.
+type ChangeType byte
+
+// Supported change types.
+const (
+	ChangeAdded   ChangeType = '+'
+	ChangeRemoved ChangeType = '-'
+	ChangeChanged ChangeType = '~'
+)
+
+// Change describes a difference between two values at a JSON pointer path.
+type Change struct {
+	Type ChangeType
+	// Path is the JSON pointer of the value that changed, e.g.
+	// `/spec/replicas`. Empty if the values differ at the root.
+	Path string
+	// Before is the previous value. Nil for added values.
+	Before interface{}
+	// After is the new value. Nil for removed values.
+	After interface{}
+}
+
+// String implements fmt.Stringer.
+func (c Change) String() string {
+	switch c.Type {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.displayPath(), compactJSON(c.After))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.displayPath(), compactJSON(c.Before))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", c.displayPath(), compactJSON(c.Before), compactJSON(c.After))
+	}
+}
+
+func (c Change) displayPath() string {
+	if c.Path == "" {
+		return "(root)"
+	}
+
+	return c.Path
+}
+
+// Changes returns the structural differences between before and after in
+// the order of their JSON pointers. Objects are compared key by key and
+// arrays item by item. Values are normalized first, so structs and maps with
+// the same JSON representation are equal.
+func Changes(before, after interface{}) ([]Change, error) {
+	nb, err := normalize(before)
+	if err != nil {
+		return nil, err
+	}
+
+	na, err := normalize(after)
+	if err != nil {
+		return nil, err
+	}
+
+	return collectChanges(nil, "", nb, na), nil
+}
+
+func collectChanges(changes []Change, path string, before, after interface{}) []Change {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, ok := after.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		keys := make(map[string]interface{}, len(a)+len(b))
+		for k := range b {
+			keys[k] = nil
+		}
+
+		for k := range a {
+			keys[k] = nil
+		}
+
+		for _, k := range sortedKeys(keys) {
+			p := path + "/" + escapePointer(k)
+
+			bv, inBefore := b[k]
+			av, inAfter := a[k]
+
+			switch {
+			case !inBefore:
+				changes = append(changes, Change{Type: ChangeAdded, Path: p, After: av})
+			case !inAfter:
+				changes = append(changes, Change{Type: ChangeRemoved, Path: p, Before: bv})
+			default:
+				changes = collectChanges(changes, p, bv, av)
+			}
+		}
+
+		return changes
+	case []interface{}:
+		a, ok := after.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := 0; i < len(a) || i < len(b); i++ {
+			p := fmt.Sprintf("%s/%d", path, i)
+
+			switch {
+			case i >= len(b):
+				changes = append(changes, Change{Type: ChangeAdded, Path: p, After: a[i]})
+			case i >= len(a):
+				changes = append(changes, Change{Type: ChangeRemoved, Path: p, Before: b[i]})
+			default:
+				changes = collectChanges(changes, p, b[i], a[i])
+			}
+		}
+
+		return changes
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		changes = append(changes, Change{Type: ChangeChanged, Path: path, Before: before, After: after})
+	}
+
+	return changes
+}
+
+// escapePointer escapes a JSON pointer reference token according to RFC
+// 6901.
+func escapePointer(s string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(s)
+}
+
+func compactJSON(v interface{}) string {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	if err := enc.Encode(v); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func structuralDiff(compiled *Compiled, before, after interface{}, colored bool) ([]byte, error) {
+	before, err := compiled.selector.selectValue(before)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err = compiled.selector.selectValue(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := Changes(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	for _, change := range changes {
+		line := change.String()
+
+		if colored {
+			line = diffColor(change.Type)(line)
+		}
+
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+func diffColor(t ChangeType) func(string) string {
+	switch t {
+	case ChangeAdded:
+		return diffAddedColor
+	case ChangeRemoved:
+		return diffRemovedColor
+	default:
+		return diffChangedColor
+	}
+}
+
+// diffLine is a line of a unified diff. Kind is one of ' ', '-' or '+'.
+type diffLine struct {
+	kind byte
+	text string
+}
+
+func unifiedDiff(from, to []byte, config *DiffConfig, colored bool) []byte {
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	context := config.Context
+	switch {
+	case context == 0:
+		context = DefaultDiffContext
+	case context < 0:
+		context = 0
+	}
+
+	hunks := diffHunks(lines, context)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	color := func(fn func(string) string, s string) string {
+		if colored {
+			return fn(s)
+		}
+
+		return s
+	}
+
+	var buf bytes.Buffer
+
+	fromLabel, toLabel := config.FromLabel, config.ToLabel
+	if fromLabel == "" {
+		fromLabel = "before"
+	}
+
+	if toLabel == "" {
+		toLabel = "after"
+	}
+
+	fmt.Fprintln(&buf, color(diffHeaderColor, "--- "+fromLabel))
+	fmt.Fprintln(&buf, color(diffHeaderColor, "+++ "+toLabel))
+
+	// fromLine and toLine hold the number of lines of each side preceding
+	// the line at the same index.
+	fromLine := make([]int, len(lines)+1)
+	toLine := make([]int, len(lines)+1)
+
+	for i, line := range lines {
+		fromLine[i+1], toLine[i+1] = fromLine[i], toLine[i]
+
+		if line.kind != '+' {
+			fromLine[i+1]++
+		}
+
+		if line.kind != '-' {
+			toLine[i+1]++
+		}
+	}
+
+	for _, h := range hunks {
+		start, end := h[0], h[1]
+
+		header := fmt.Sprintf("@@ -%s +%s @@",
+			hunkRange(fromLine[start], fromLine[end]-fromLine[start]),
+			hunkRange(toLine[start], toLine[end]-toLine[start]))
+
+		fmt.Fprintln(&buf, color(diffHunkColor, header))
+
+		for _, line := range lines[start:end] {
+			text := string(line.kind) + line.text
+
+			switch line.kind {
+			case '-':
+				text = color(diffRemovedColor, text)
+			case '+':
+				text = color(diffAddedColor, text)
+			}
+
+			fmt.Fprintln(&buf, text)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// hunkRange formats the range of a hunk header. Start is the number of lines
+// preceding the hunk. Like GNU diff, the length is omitted if it is 1 and
+// empty ranges start at the line preceding the hunk.
+func hunkRange(start, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprintf("%d", start+1)
+	default:
+		return fmt.Sprintf("%d,%d", start+1, length)
+	}
+}
+
+// diffHunks groups lines into hunks of changes with the given number of
+// context lines. Returns the start and end index of each hunk. Hunks whose
+// context lines would overlap are merged.
+func diffHunks(lines []diffLine, context int) [][2]int {
+	var hunks [][2]int
+
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+
+		last := i
+
+		for j := i; j < len(lines); j++ {
+			if lines[j].kind != ' ' {
+				last = j
+			} else if j-last > 2*context {
+				break
+			}
+		}
+
+		end := last + context + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+
+		hunks = append(hunks, [2]int{start, end})
+		i = end
+	}
+
+	return hunks
+}
+
+// diffLines computes the line-based differences between a and b using the
+// linear space refinement of the algorithm by Myers, which finds a shortest
+// edit script in O((N+M)·D) time and O(N+M) space.
+func diffLines(a, b []string) []diffLine {
+	n := len(a) + len(b) + 1
+
+	d := &myersDiff{
+		a:     a,
+		b:     b,
+		lines: make([]diffLine, 0, len(a)+len(b)),
+		vf:    make([]int, 2*n+1),
+		vb:    make([]int, 2*n+1),
+		off:   n,
+	}
+
+	d.compare(0, len(a), 0, len(b))
+
+	return groupChanges(d.lines)
+}
+
+// groupChanges reorders each run of consecutive changed lines in place, so
+// that removed lines precede added lines.
+func groupChanges(lines []diffLine) []diffLine {
+	for i := 0; i < len(lines); {
+		if lines[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(lines) && lines[j].kind != ' ' {
+			j++
+		}
+
+		run := lines[i:j]
+		sort.SliceStable(run, func(a, b int) bool {
+			return run[a].kind == '-' && run[b].kind == '+'
+		})
+
+		i = j
+	}
+
+	return lines
+}
+
+// myersDiff holds the state of diffLines. Vf and vb hold the furthest
+// reaching x positions of the forward and backward paths per diagonal k,
+// which is stored at index off+k.
+type myersDiff struct {
+	a, b   []string
+	lines  []diffLine
+	vf, vb []int
+	off    int
+}
+
+// compare appends the differences between a[aLo:aHi] and b[bLo:bHi] to
+// d.lines.
+func (d *myersDiff) compare(aLo, aHi, bLo, bHi int) {
+	// Common prefixes and suffixes are stripped to keep the search space
+	// small for the usual case of few changes.
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.lines = append(d.lines, diffLine{' ', d.a[aLo]})
+		aLo++
+		bLo++
+	}
+
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-1-suffix] == d.b[bHi-1-suffix] {
+		suffix++
+	}
+
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for _, line := range d.b[bLo:bHi] {
+			d.lines = append(d.lines, diffLine{'+', line})
+		}
+	case bLo == bHi:
+		for _, line := range d.a[aLo:aHi] {
+			d.lines = append(d.lines, diffLine{'-', line})
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+
+		d.compare(aLo, x, bLo, y)
+
+		for _, line := range d.a[x:u] {
+			d.lines = append(d.lines, diffLine{' ', line})
+		}
+
+		d.compare(u, aHi, v, bHi)
+	}
+
+	for _, line := range d.a[aHi : aHi+suffix] {
+		d.lines = append(d.lines, diffLine{' ', line})
+	}
+}
+
+// middleSnake finds the middle snake of an optimal path through the edit
+// graph of a[aLo:aHi] and b[bLo:bHi] by searching forward from the start and
+// backward from the end simultaneously until the paths overlap. Returns the
+// start (x, y) and end (u, v) of the snake. Both ranges must be non-empty.
+func (d *myersDiff) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	vf, vb, off := d.vf, d.vb, d.off
+
+	vf[off+1], vb[off+1] = 0, 0
+
+	for D := 0; D <= (n+m+1)/2; D++ {
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || k != D && vf[off+k-1] < vf[off+k+1] {
+				x = vf[off+k+1]
+			} else {
+				x = vf[off+k-1] + 1
+			}
+
+			y := x - k
+			x0, y0 := x, y
+
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+
+			vf[off+k] = x
+
+			// The backward path on the same diagonal was extended D-1
+			// times so far.
+			if kb := delta - k; odd && kb >= -(D-1) && kb <= D-1 && x+vb[off+kb] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || k != D && vb[off+k-1] < vb[off+k+1] {
+				x = vb[off+k+1]
+			} else {
+				x = vb[off+k-1] + 1
+			}
+
+			y := x - k
+			x0, y0 := x, y
+
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+
+			vb[off+k] = x
+
+			if kf := delta - k; !odd && kf >= -D && kf <= D && x+vf[off+kf] >= n {
+				return aHi - x, bHi - y, aHi - x0, bHi - y0
+			}
+		}
+	}
+
+	// Unreachable: the paths overlap after at most (n+m+1)/2 steps.
+	panic("diff: no middle snake found")
+}
+
+// splitLines splits buf into lines. A trailing newline does not produce an
+// empty last line.
+func splitLines(buf []byte) []string {
+	s := strings.TrimSuffix(string(buf), "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
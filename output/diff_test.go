@@ -0,0 +1,253 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffBytes(t *testing.T) {
+	before := map[string]interface{}{
+		"name":     "app",
+		"replicas": 1,
+		"image":    "nginx:1.0",
+		"labels":   map[string]interface{}{"team": "a", "tier": "web"},
+		"ports":    []interface{}{80, 443},
+	}
+
+	after := map[string]interface{}{
+		"name":     "app",
+		"replicas": 3,
+		"image":    "nginx:1.0",
+		"labels":   map[string]interface{}{"team": "a", "env": "prod/eu"},
+		"ports":    []interface{}{80},
+	}
+
+	tests := []struct {
+		name       string
+		cfg        Config
+		diffConfig *DiffConfig
+		before     interface{}
+		after      interface{}
+		want       string
+		err        error
+	}{
+		{
+			name:   "unified yaml",
+			cfg:    Config{Format: "yaml"},
+			before: before,
+			after:  after,
+			want: `--- before
++++ after
+@@ -1,9 +1,8 @@
+ image: nginx:1.0
+ labels:
++  env: prod/eu
+   team: a
+-  tier: web
+ name: app
+ ports:
+ - 80
+-- 443
+-replicas: 1
++replicas: 3
+`,
+		},
+		{
+			name:       "unified json with context and labels",
+			cfg:        Config{Format: "json", JSONPointer: "/labels"},
+			diffConfig: &DiffConfig{Context: -1, FromLabel: "old.json", ToLabel: "new.json"},
+			before:     before,
+			after:      after,
+			want: `--- old.json
++++ new.json
+@@ -2,2 +2,2 @@
+-  "team": "a",
+-  "tier": "web"
++  "env": "prod/eu",
++  "team": "a"
+`,
+		},
+		{
+			name:       "separate hunks",
+			cfg:        Config{Format: "yaml"},
+			diffConfig: &DiffConfig{Context: 1},
+			before:     []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+			after:      []int{0, 2, 3, 4, 5, 6, 7, 8, 9, 11},
+			want: `--- before
++++ after
+@@ -1,2 +1,2 @@
+-- 1
++- 0
+ - 2
+@@ -9,2 +9,2 @@
+ - 9
+-- 10
++- 11
+`,
+		},
+		{
+			name:   "no differences",
+			cfg:    Config{Format: "table"},
+			before: before,
+			after:  before,
+			want:   "",
+		},
+		{
+			name:       "structural",
+			cfg:        Config{Format: "yaml"},
+			diffConfig: &DiffConfig{Structural: true},
+			before:     before,
+			after:      after,
+			want: `+ /labels/env: "prod/eu"
+- /labels/tier: "web"
+- /ports/1: 443
+~ /replicas: 1 -> 3
+`,
+		},
+		{
+			name:       "structural ignores key order and struct types",
+			cfg:        Config{Format: "json"},
+			diffConfig: &DiffConfig{Structural: true},
+			before:     struct{ B, A int }{1, 2},
+			after:      map[string]interface{}{"A": 2, "B": 1},
+			want:       "",
+		},
+		{
+			name:       "structural root and escaping",
+			cfg:        Config{Format: "json", JSONPointer: "/a~1b"},
+			diffConfig: &DiffConfig{Structural: true},
+			before:     map[string]interface{}{"a/b": "foo"},
+			after:      map[string]interface{}{"a/b": []interface{}{"foo"}},
+			want:       "~ (root): \"foo\" -> [\"foo\"]\n",
+		},
+		{
+			name:   "invalid json pointer",
+			cfg:    Config{Format: "json", JSONPointer: "/foo"},
+			before: before,
+			after:  after,
+			err:    errors.New(`/foo at part 0: couldn't find key "foo"`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := DiffBytes(test.before, test.after, &test.cfg, test.diffConfig)
+			if test.err != nil {
+				require.EqualError(t, err, test.err.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.want, string(got))
+			}
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	var buf bytes.Buffer
+
+	err := Diff(&buf, "foo", "bar", &Config{Format: "json", Color: ColorAlways}, nil)
+	require.NoError(t, err)
+
+	want := diffHeaderColor("--- before") + "\n" +
+		diffHeaderColor("+++ after") + "\n" +
+		diffHunkColor("@@ -1 +1 @@") + "\n" +
+		diffRemovedColor(`-"foo"`) + "\n" +
+		diffAddedColor(`+"bar"`) + "\n"
+
+	require.Equal(t, want, buf.String())
+}
+
+func TestChanges(t *testing.T) {
+	changes, err := Changes(
+		map[string]interface{}{"a": map[string]interface{}{"b~": 1}},
+		map[string]interface{}{"a": map[string]interface{}{"b~": 2}, "c": nil},
+	)
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Type: ChangeChanged, Path: "/a/b~0", Before: json.Number("1"), After: json.Number("2")},
+		{Type: ChangeAdded, Path: "/c"},
+	}, changes)
+}
+
+func TestDiffLines(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	randomLines := func() []string {
+		var lines []string
+
+		for n := rnd.Intn(12); n > 0; n-- {
+			lines = append(lines, string(rune('a'+rnd.Intn(4))))
+		}
+
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+
+		var from, to []string
+
+		edits := 0
+
+		for _, line := range diffLines(a, b) {
+			switch line.kind {
+			case ' ':
+				from, to = append(from, line.text), append(to, line.text)
+			case '-':
+				from = append(from, line.text)
+				edits++
+			case '+':
+				to = append(to, line.text)
+				edits++
+			}
+		}
+
+		require.Equal(t, a, from, "a=%q b=%q", a, b)
+		require.Equal(t, b, to, "a=%q b=%q", a, b)
+		require.Equal(t, len(a)+len(b)-2*lcsLength(a, b), edits, "a=%q b=%q", a, b)
+	}
+}
+
+func TestDiffLines_large(t *testing.T) {
+	a := make([]string, 50000)
+	for i := range a {
+		a[i] = strconv.Itoa(i)
+	}
+
+	b := append([]string{"first"}, a...)
+	b[25000] = "changed"
+	b = append(b, "last")
+
+	lines := diffLines(a, b)
+	require.Equal(t, diffLine{'+', "first"}, lines[0])
+	require.Equal(t, diffLine{'+', "last"}, lines[len(lines)-1])
+	require.Len(t, lines, len(a)+3)
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b.
+func lcsLength(a, b []string) int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				cur[j+1] = prev[j] + 1
+			case prev[j+1] >= cur[j]:
+				cur[j+1] = prev[j+1]
+			default:
+				cur[j+1] = cur[j]
+			}
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,28 @@
+// Package output formats arbitrary values using a configurable set of
+// formatters like json, yaml, table or gotemplate.
+//
+// Struct tags
+//
+// Struct fields can be annotated with an `output` struct tag to control how
+// they are formatted:
+//
+//   type User struct {
+//     Name     string `json:"name" output:"NAME"`
+//     Email    string `json:"email" output:"E-MAIL,wide"`
+//     Team     string `json:"team,omitempty" output:",omitempty"`
+//     Password string `json:"password" output:"-,sensitive"`
+//   }
+//
+// The first part of the tag is the column header used by table-like formats
+// (table, csv and tsv) instead of the field's key. Headers can also be used
+// to select columns via Config.Columns. A tag of "-" excludes the field from
+// table-like formats. The following options are supported:
+//
+//   wide       only include the column if Config.Wide is true
+//   omitempty  exclude the column if the field is empty in all rows
+//   sensitive  mask the value in the output of all formatters
+//
+// Sensitive string fields are replaced with "***" unless they are empty, all
+// other sensitive fields are reset to their zero value. Object keys and field
+// names in non-tabular formats are still governed by the json struct tag.
+package output
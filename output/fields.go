@@ -0,0 +1,805 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// sensitiveMask replaces the values of non-empty sensitive string fields.
+const sensitiveMask = "***"
+
+// outputTag is the parsed representation of an `output` struct tag.
+type outputTag struct {
+	// name is the header of the column for the field in table-like formats.
+	name string
+	// hidden is true if the name is "-". Hidden fields are excluded from
+	// table-like formats.
+	hidden bool
+	// wide fields are only included in table-like formats if Config.Wide is
+	// true.
+	wide bool
+	// omitEmpty fields are excluded from table-like formats if their value
+	// is empty in all rows.
+	omitEmpty bool
+	// sensitive fields are masked in the output of all formatters.
+	sensitive bool
+}
+
+// parseOutputTag parses an `output` struct tag of the form
+// `NAME,wide,omitempty,sensitive`. All parts are optional. A NAME of "-"
+// hides the field. Unknown options are ignored.
+func parseOutputTag(tag string) outputTag {
+	parts := strings.Split(tag, ",")
+
+	ot := outputTag{name: parts[0]}
+
+	if ot.name == "-" {
+		ot.name, ot.hidden = "", true
+	}
+
+	for _, opt := range parts[1:] {
+		switch opt {
+		case "wide":
+			ot.wide = true
+		case "omitempty":
+			ot.omitEmpty = true
+		case "sensitive":
+			ot.sensitive = true
+		}
+	}
+
+	return ot
+}
+
+// structField holds the metadata of a struct field that is relevant for
+// formatting.
+type structField struct {
+	// key is the name that encoding/json uses for the field.
+	key string
+	// index is the index sequence of the field for reflect.Value.FieldByIndex.
+	index []int
+	// typ is the type of the field.
+	typ reflect.Type
+	// omitEmptyJSON is true if the json tag contains the omitempty option.
+	omitEmptyJSON bool
+	// stringJSON is true if the json tag contains the string option.
+	stringJSON bool
+	// embeddedPtr is true if the field is promoted through an embedded
+	// struct pointer. Such fields are omitted by encoding/json if the
+	// pointer is nil.
+	embeddedPtr bool
+	// tagged is true if the json tag sets the name of the field.
+	tagged bool
+	outputTag
+}
+
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the fields of struct type t which are encoded by
+// encoding/json in declaration order. Fields of embedded structs without a
+// json tag are promoted following the rules of encoding/json: shallower
+// fields take precedence over deeper ones and conflicting fields at the same
+// depth are dropped unless exactly one of them is tagged. The result is
+// cached.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	fields, _ := structFieldsCache.LoadOrStore(t, typeFields(t))
+
+	return fields.([]structField)
+}
+
+// typeFields resolves the fields of struct type t. It is a port of the field
+// resolution of encoding/json, which explores embedded structs breadth-first.
+func typeFields(t reflect.Type) []structField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+		ptr   bool
+	}
+
+	var (
+		current []embedded
+		next    = []embedded{{typ: t}}
+		// count and nextCount count how often a struct type is embedded at
+		// the current and the next depth.
+		count     map[reflect.Type]int
+		nextCount = map[reflect.Type]int{}
+		visited   = map[reflect.Type]bool{}
+		fields    []structField
+	)
+
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		count, nextCount = nextCount, map[reflect.Type]int{}
+
+		for _, e := range current {
+			if visited[e.typ] {
+				continue
+			}
+
+			visited[e.typ] = true
+
+			for i := 0; i < e.typ.NumField(); i++ {
+				sf := e.typ.Field(i)
+
+				if sf.Anonymous {
+					ft := sf.Type
+					if ft.Kind() == reflect.Ptr {
+						ft = ft.Elem()
+					}
+
+					if sf.PkgPath != "" && ft.Kind() != reflect.Struct {
+						// Embedded fields of unexported non-struct types
+						// are ignored.
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					// Unexported field.
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+
+				name, opts := parseJSONTag(tag)
+
+				index := make([]int, len(e.index)+1)
+				copy(index, e.index)
+				index[len(e.index)] = i
+
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+
+				if name != "" || !sf.Anonymous || ft.Kind() != reflect.Struct {
+					field := structField{
+						key:           name,
+						index:         index,
+						typ:           sf.Type,
+						omitEmptyJSON: hasJSONOption(opts, "omitempty"),
+						stringJSON:    hasJSONOption(opts, "string"),
+						embeddedPtr:   e.ptr,
+						tagged:        name != "",
+						outputTag:     parseOutputTag(sf.Tag.Get("output")),
+					}
+
+					if field.key == "" {
+						field.key = sf.Name
+					}
+
+					fields = append(fields, field)
+
+					if count[e.typ] > 1 {
+						// The enclosing struct is embedded multiple times
+						// at the same depth, which makes its fields
+						// conflict with each other. Adding a copy makes
+						// sure that the conflict is detected below.
+						fields = append(fields, field)
+					}
+
+					continue
+				}
+
+				nextCount[ft]++
+				if nextCount[ft] == 1 {
+					next = append(next, embedded{
+						typ:   ft,
+						index: index,
+						ptr:   e.ptr || sf.Type.Kind() == reflect.Ptr,
+					})
+				}
+			}
+		}
+	}
+
+	return dominantFields(fields)
+}
+
+// dominantFields removes fields which are hidden by shallower fields with the
+// same key or which conflict with other fields at the same depth. The
+// remaining fields are returned in declaration order.
+func dominantFields(fields []structField) []structField {
+	sort.SliceStable(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+
+		switch {
+		case a.key != b.key:
+			return a.key < b.key
+		case len(a.index) != len(b.index):
+			return len(a.index) < len(b.index)
+		case a.tagged != b.tagged:
+			return a.tagged
+		default:
+			return indexLess(a.index, b.index)
+		}
+	})
+
+	out := fields[:0]
+
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].key == fields[i].key {
+			j++
+		}
+
+		group := fields[i:j]
+		i = j
+
+		// The group is sorted by depth and tagged fields come first. The
+		// first field dominates unless another field at the same depth has
+		// the same precedence.
+		if len(group) > 1 && len(group[0].index) == len(group[1].index) && group[0].tagged == group[1].tagged {
+			continue
+		}
+
+		out = append(out, group[0])
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return indexLess(out[i].index, out[j].index)
+	})
+
+	return out
+}
+
+func indexLess(a, b []int) bool {
+	for k, x := range a {
+		if k >= len(b) {
+			return false
+		}
+
+		if x != b[k] {
+			return x < b[k]
+		}
+	}
+
+	return len(a) < len(b)
+}
+
+// hasJSONOption returns true if the comma-separated json tag options opts
+// contain option.
+func hasJSONOption(opts, option string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if opt == option {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldSet holds the struct field metadata of column keys.
+type fieldSet map[string]structField
+
+// addType adds the fields of t to the set if t is a struct type. Fields that
+// are already present are not overridden.
+func (s fieldSet) addType(t reflect.Type) {
+	t = indirectType(t)
+	if t.Kind() != reflect.Struct {
+		return
+	}
+
+	for _, field := range structFields(t) {
+		if _, ok := s[field.key]; !ok {
+			s[field.key] = field
+		}
+	}
+}
+
+// maskSensitive returns v with the values of all struct fields tagged as
+// sensitive replaced: non-empty strings are replaced with "***", all other
+// values are reset to their zero value. V is not modified, the values
+// containing sensitive fields are copied instead. Returns v as is if it does
+// not contain sensitive fields. Returns an error if v contains a cycle.
+func maskSensitive(v interface{}) (interface{}, error) {
+	var m masker
+
+	v, _, err := m.mask(v)
+
+	return v, err
+}
+
+// startDetectingCyclesAfter is the nesting depth after which the masker
+// starts to track visited values to detect cycles. Tracking is deferred to
+// keep the common case of shallow values cheap, like encoding/json does.
+const startDetectingCyclesAfter = 1000
+
+// masker masks sensitive struct fields and detects cycles in the masked
+// value.
+type masker struct {
+	depth int
+	seen  map[visit]struct{}
+}
+
+// visit identifies a pointer, map or slice value for cycle detection.
+type visit struct {
+	typ reflect.Type
+	ptr uintptr
+	len int
+}
+
+// enter must be called before descending into the pointer, map or slice
+// value rv. Returns an error if rv was already entered before without leaving
+// it. Each successful call must be followed by a call to leave.
+func (m *masker) enter(rv reflect.Value) error {
+	m.depth++
+
+	if m.depth <= startDetectingCyclesAfter {
+		return nil
+	}
+
+	if m.seen == nil {
+		m.seen = make(map[visit]struct{})
+	}
+
+	key := visit{typ: rv.Type(), ptr: rv.Pointer()}
+	if rv.Kind() == reflect.Slice {
+		key.len = rv.Len()
+	}
+
+	if _, ok := m.seen[key]; ok {
+		m.depth--
+		return fmt.Errorf("encountered a cycle via %s", rv.Type())
+	}
+
+	m.seen[key] = struct{}{}
+
+	return nil
+}
+
+func (m *masker) leave(rv reflect.Value) {
+	if m.depth > startDetectingCyclesAfter {
+		key := visit{typ: rv.Type(), ptr: rv.Pointer()}
+		if rv.Kind() == reflect.Slice {
+			key.len = rv.Len()
+		}
+
+		delete(m.seen, key)
+	}
+
+	m.depth--
+}
+
+// mask masks sensitive fields in v. Maps and slices of the types produced by
+// encoding/json and scalars are handled without reflection. Returns a copy of
+// v and true if anything was masked, v itself and false otherwise.
+func (m *masker) mask(v interface{}) (interface{}, bool, error) {
+	switch v := v.(type) {
+	case nil, string, bool, float64, json.Number:
+		return v, false, nil
+	case map[string]interface{}:
+		return m.maskMap(v)
+	case []interface{}:
+		return m.maskSlice(v)
+	}
+
+	rv, changed, err := m.maskValue(reflect.ValueOf(v))
+	if err != nil || !changed {
+		return v, false, err
+	}
+
+	return rv.Interface(), true, nil
+}
+
+func (m *masker) maskMap(v map[string]interface{}) (interface{}, bool, error) {
+	if v == nil {
+		return v, false, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if err := m.enter(rv); err != nil {
+		return nil, false, err
+	}
+	defer m.leave(rv)
+
+	var out map[string]interface{}
+
+	for key, elem := range v {
+		masked, changed, err := m.mask(elem)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !changed {
+			continue
+		}
+
+		if out == nil {
+			out = make(map[string]interface{}, len(v))
+			for k, e := range v {
+				out[k] = e
+			}
+		}
+
+		out[key] = masked
+	}
+
+	if out == nil {
+		return v, false, nil
+	}
+
+	return out, true, nil
+}
+
+func (m *masker) maskSlice(v []interface{}) (interface{}, bool, error) {
+	if v == nil {
+		return v, false, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	if err := m.enter(rv); err != nil {
+		return nil, false, err
+	}
+	defer m.leave(rv)
+
+	var out []interface{}
+
+	for i, elem := range v {
+		masked, changed, err := m.mask(elem)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !changed {
+			continue
+		}
+
+		if out == nil {
+			out = make([]interface{}, len(v))
+			copy(out, v)
+		}
+
+		out[i] = masked
+	}
+
+	if out == nil {
+		return v, false, nil
+	}
+
+	return out, true, nil
+}
+
+// maskValue masks sensitive fields in rv. Returns a copy of rv and true if
+// anything was masked, rv itself and false otherwise.
+func (m *masker) maskValue(rv reflect.Value) (reflect.Value, bool, error) {
+	if !mayContainSensitive(rv.Type()) {
+		return rv, false, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return rv, false, nil
+		}
+
+		if err := m.enter(rv); err != nil {
+			return rv, false, err
+		}
+		defer m.leave(rv)
+
+		elem, changed, err := m.maskValue(rv.Elem())
+		if err != nil || !changed {
+			return rv, false, err
+		}
+
+		p := reflect.New(rv.Type().Elem())
+		p.Elem().Set(elem)
+
+		return p, true, nil
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv, false, nil
+		}
+
+		var (
+			elem    reflect.Value
+			changed bool
+			err     error
+		)
+
+		switch e := rv.Elem(); e.Type() {
+		case plainMapType, plainSliceType:
+			var masked interface{}
+
+			masked, changed, err = m.mask(e.Interface())
+			elem = reflect.ValueOf(masked)
+		default:
+			elem, changed, err = m.maskValue(e)
+		}
+
+		if err != nil || !changed {
+			return rv, false, err
+		}
+
+		out := reflect.New(rv.Type()).Elem()
+		out.Set(elem)
+
+		return out, true, nil
+	case reflect.Struct:
+		return m.maskStruct(rv)
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice {
+			if rv.IsNil() {
+				return rv, false, nil
+			}
+
+			if err := m.enter(rv); err != nil {
+				return rv, false, err
+			}
+			defer m.leave(rv)
+		}
+
+		var out reflect.Value
+
+		for i := 0; i < rv.Len(); i++ {
+			elem, changed, err := m.maskValue(rv.Index(i))
+			if err != nil {
+				return rv, false, err
+			}
+
+			if !changed {
+				continue
+			}
+
+			if !out.IsValid() {
+				out = copySliceOrArray(rv)
+			}
+
+			out.Index(i).Set(elem)
+		}
+
+		if !out.IsValid() {
+			return rv, false, nil
+		}
+
+		return out, true, nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv, false, nil
+		}
+
+		if err := m.enter(rv); err != nil {
+			return rv, false, err
+		}
+		defer m.leave(rv)
+
+		var out reflect.Value
+
+		iter := rv.MapRange()
+		for iter.Next() {
+			elem, changed, err := m.maskValue(iter.Value())
+			if err != nil {
+				return rv, false, err
+			}
+
+			if !changed {
+				continue
+			}
+
+			if !out.IsValid() {
+				out = reflect.MakeMapWithSize(rv.Type(), rv.Len())
+
+				copyIter := rv.MapRange()
+				for copyIter.Next() {
+					out.SetMapIndex(copyIter.Key(), copyIter.Value())
+				}
+			}
+
+			out.SetMapIndex(iter.Key(), elem)
+		}
+
+		if !out.IsValid() {
+			return rv, false, nil
+		}
+
+		return out, true, nil
+	default:
+		return rv, false, nil
+	}
+}
+
+var (
+	plainMapType   = reflect.TypeOf(map[string]interface{}(nil))
+	plainSliceType = reflect.TypeOf([]interface{}(nil))
+)
+
+func (m *masker) maskStruct(rv reflect.Value) (reflect.Value, bool, error) {
+	out := reflect.New(rv.Type()).Elem()
+	out.Set(rv)
+
+	changed, err := m.maskFields(out)
+	if err != nil || !changed {
+		return rv, false, err
+	}
+
+	return out, true, nil
+}
+
+// maskFields masks the sensitive fields of the addressable struct value rv in
+// place. Returns true if anything was masked.
+func (m *masker) maskFields(rv reflect.Value) (bool, error) {
+	t := rv.Type()
+	changed := false
+
+	for i := 0; i < t.NumField(); i++ {
+		field := rv.Field(i)
+
+		if !field.CanSet() {
+			// The exported fields of embedded structs of unexported type
+			// are encoded as well and need to be masked in place.
+			if t.Field(i).Anonymous && field.Kind() == reflect.Struct {
+				ok, err := m.maskFields(field)
+				if err != nil {
+					return false, err
+				}
+
+				changed = ok || changed
+			}
+
+			continue
+		}
+
+		var (
+			masked reflect.Value
+			ok     bool
+			err    error
+		)
+
+		if parseOutputTag(t.Field(i).Tag.Get("output")).sensitive {
+			masked, ok = maskField(field)
+		} else {
+			masked, ok, err = m.maskValue(field)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if ok {
+			field.Set(masked)
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+// maskField masks the value of a sensitive field.
+func maskField(rv reflect.Value) (reflect.Value, bool) {
+	switch {
+	case rv.Kind() == reflect.String:
+		if rv.Len() == 0 {
+			return rv, false
+		}
+
+		out := reflect.New(rv.Type()).Elem()
+		out.SetString(sensitiveMask)
+
+		return out, true
+	case rv.Kind() == reflect.Ptr && rv.Type().Elem().Kind() == reflect.String:
+		if rv.IsNil() {
+			return rv, false
+		}
+
+		masked, changed := maskField(rv.Elem())
+		if !changed {
+			return rv, false
+		}
+
+		p := reflect.New(rv.Type().Elem())
+		p.Elem().Set(masked)
+
+		return p, true
+	case rv.IsZero():
+		return rv, false
+	default:
+		return reflect.Zero(rv.Type()), true
+	}
+}
+
+// isSensitiveField returns true if part selects a struct field tagged as
+// sensitive from rv. Fields are looked up like pointerstructure does it:
+// by their `pointer` tag or by their name.
+func isSensitiveField(rv reflect.Value, part string) bool {
+	for rv.Kind() == reflect.Interface || rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return false
+	}
+
+	t := rv.Type()
+	sensitive := false
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name, _ := parseJSONTag(field.Tag.Get("pointer"))
+
+		switch {
+		case name == part:
+			return parseOutputTag(field.Tag.Get("output")).sensitive
+		case name == "" && field.Name == part:
+			sensitive = parseOutputTag(field.Tag.Get("output")).sensitive
+		}
+	}
+
+	return sensitive
+}
+
+func copySliceOrArray(rv reflect.Value) reflect.Value {
+	if rv.Kind() == reflect.Array {
+		out := reflect.New(rv.Type()).Elem()
+		out.Set(rv)
+		return out
+	}
+
+	out := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+	reflect.Copy(out, rv)
+
+	return out
+}
+
+var sensitiveTypeCache sync.Map // map[reflect.Type]bool
+
+// mayContainSensitive returns true if values of type t may contain struct
+// fields tagged as sensitive. Interface types may hold any value and are
+// therefore always considered to possibly contain sensitive fields.
+func mayContainSensitive(t reflect.Type) bool {
+	if ok, found := sensitiveTypeCache.Load(t); found {
+		return ok.(bool)
+	}
+
+	ok := containsSensitive(t, make(map[reflect.Type]bool))
+
+	sensitiveTypeCache.Store(t, ok)
+
+	return ok
+}
+
+func containsSensitive(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		// Recursive types only contain sensitive fields if another path
+		// leads to them.
+		return false
+	}
+
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+		return containsSensitive(t.Elem(), seen)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+
+			if field.PkgPath != "" && !field.Anonymous {
+				continue
+			}
+
+			if parseOutputTag(field.Tag.Get("output")).sensitive || containsSensitive(field.Type, seen) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return false
+	}
+}
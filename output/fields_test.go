@@ -0,0 +1,225 @@
+package output
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fieldsTestUser struct {
+	Name     string  `json:"name" output:"USER"`
+	Email    string  `json:"email" output:"E-MAIL,wide"`
+	Team     string  `json:"team,omitempty" output:",omitempty"`
+	Password string  `json:"password" output:"-,sensitive"`
+	Token    *string `json:"token,omitempty" output:",sensitive"`
+	PIN      int     `json:"pin,omitempty" output:",sensitive"`
+}
+
+type fieldsTestCredentials struct {
+	credentials
+	Users map[string]interface{} `json:"users"`
+}
+
+type credentials struct {
+	Secret string `json:"secret" output:",sensitive"`
+}
+
+func TestFormat_outputTags(t *testing.T) {
+	token := "abc"
+
+	users := []fieldsTestUser{
+		{Name: "foo", Email: "foo@example.com", Password: "secret", Token: &token, PIN: 1234},
+		{Name: "bar", Email: "bar@example.com"},
+	}
+
+	tests := []formatTestCase{
+		{
+			name: "table headers, hidden, wide and omitempty columns",
+			cfg:  Config{Format: "table"},
+			v:    users,
+			want: "USER   TOKEN   PIN\nfoo    ***\nbar\n",
+		},
+		{
+			name: "table wide",
+			cfg:  Config{Format: "table", Wide: true},
+			v:    users,
+			want: "USER   E-MAIL            TOKEN   PIN\nfoo    foo@example.com   ***\nbar    bar@example.com\n",
+		},
+		{
+			name: "omitempty column with values",
+			cfg:  Config{Format: "table"},
+			v:    []fieldsTestUser{{Name: "foo", Team: "a"}},
+			want: "USER   TEAM   TOKEN   PIN\nfoo    a\n",
+		},
+		{
+			name: "columns match headers",
+			cfg:  Config{Format: "table", Columns: []string{"e-mail", "name", "password"}},
+			v:    users[:1],
+			want: "E-MAIL            USER   PASSWORD\nfoo@example.com   foo    ***\n",
+		},
+		{
+			name: "single object",
+			cfg:  Config{Format: "table"},
+			v:    users[1],
+			want: "USER    bar\ntoken\npin\n",
+		},
+		{
+			name: "csv headers",
+			cfg:  Config{Format: "csv", Wide: true},
+			v:    users,
+			want: "USER,E-MAIL,token,pin\nfoo,foo@example.com,***,\nbar,bar@example.com,,\n",
+		},
+		{
+			name: "json masks sensitive fields",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"compact": true}},
+			v:    users,
+			want: `[{"name":"foo","email":"foo@example.com","password":"***","token":"***"},{"name":"bar","email":"bar@example.com","password":""}]`,
+		},
+		{
+			name: "yaml masks nested and embedded sensitive fields",
+			cfg:  Config{Format: "yaml"},
+			v: fieldsTestCredentials{
+				credentials: credentials{Secret: "s3cr3t"},
+				Users:       map[string]interface{}{"foo": &users[0]},
+			},
+			want: "secret: '***'\nusers:\n  foo:\n    email: foo@example.com\n    name: foo\n    password: '***'\n    token: '***'\n",
+		},
+		{
+			name: "template masks sensitive fields",
+			cfg:  Config{Format: "gotemplate", Template: "{{.Name}}:{{.Password}}"},
+			v:    users[0],
+			want: "foo:***",
+		},
+		{
+			name: "sensitive fields cannot be selected",
+			cfg:  Config{Format: "json", JSONPointer: "/0/Password"},
+			v:    users,
+			want: `"***"`,
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+
+	// The original values must not be modified.
+	require.Equal(t, "secret", users[0].Password)
+	require.Equal(t, "abc", *users[0].Token)
+	require.Equal(t, 1234, users[0].PIN)
+}
+
+func TestEncoder_outputTags(t *testing.T) {
+	var buf bytes.Buffer
+
+	enc, err := NewEncoder(&buf, &Config{Format: "csv"})
+	require.NoError(t, err)
+
+	require.NoError(t, enc.Encode(fieldsTestUser{Name: "foo", Email: "foo@example.com", Password: "secret"}))
+	require.NoError(t, enc.Encode(fieldsTestUser{Name: "bar", Team: "b"}))
+	require.NoError(t, enc.Close())
+
+	require.Equal(t, "USER,token,pin\nfoo,,\nbar,,\n", buf.String())
+}
+
+func TestMaskSensitive(t *testing.T) {
+	plain := map[string]interface{}{"foo": []interface{}{"bar"}}
+	requireMasked(t, plain, plain)
+
+	arr := [1]credentials{{Secret: "foo"}}
+	requireMasked(t, [1]credentials{{Secret: "***"}}, arr)
+	require.Equal(t, "foo", arr[0].Secret)
+
+	var iface interface{} = &credentials{Secret: "foo"}
+	requireMasked(t, []interface{}{&credentials{Secret: "***"}}, []interface{}{iface})
+
+	nested := map[string]interface{}{"items": []interface{}{credentials{Secret: "foo"}}}
+	requireMasked(t, map[string]interface{}{"items": []interface{}{credentials{Secret: "***"}}}, nested)
+	require.Equal(t, credentials{Secret: "foo"}, nested["items"].([]interface{})[0])
+}
+
+func requireMasked(t *testing.T, expected, v interface{}) {
+	t.Helper()
+
+	masked, err := maskSensitive(v)
+	require.NoError(t, err)
+	require.Equal(t, expected, masked)
+}
+
+type fieldsTestNode struct {
+	Secret string `json:"secret" output:",sensitive"`
+	Next   *fieldsTestNode
+}
+
+func TestMaskSensitive_cycle(t *testing.T) {
+	node := &fieldsTestNode{Secret: "foo"}
+	node.Next = node
+
+	_, err := maskSensitive(node)
+	require.EqualError(t, err, "encountered a cycle via *output.fieldsTestNode")
+
+	m := map[string]interface{}{"name": "foo"}
+	m["self"] = m
+
+	_, err = maskSensitive(m)
+	require.EqualError(t, err, "encountered a cycle via map[string]interface {}")
+
+	_, err = FormatString(node, &Config{Format: "json"})
+	require.EqualError(t, err, "encountered a cycle via *output.fieldsTestNode")
+}
+
+func TestFormat_sensitivePointer(t *testing.T) {
+	v := map[string]interface{}{
+		"creds": &credentials{Secret: "foo"},
+		"users": fieldsTestCredentials{credentials: credentials{Secret: "bar"}},
+	}
+
+	out, err := FormatString(v, &Config{Format: "json", JSONPointer: "/creds/Secret"})
+	require.NoError(t, err)
+	require.Equal(t, `"***"`, out)
+
+	out, err = FormatString(v, &Config{Format: "json", JSONPointer: "/users", Query: "$.secret"})
+	require.NoError(t, err)
+	require.Equal(t, `"***"`, out)
+}
+
+type fieldsTestInner struct {
+	Name   string `json:"name"`
+	Shared string
+	Tagged string
+}
+
+type fieldsTestOther struct {
+	Shared string
+	Tagged string `json:"Tagged"`
+}
+
+type fieldsTestOuter struct {
+	fieldsTestInner
+	*fieldsTestOther
+	Name string `json:"name"`
+}
+
+func TestStructFields(t *testing.T) {
+	fields := structFields(reflect.TypeOf(fieldsTestOuter{}))
+
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = field.key
+	}
+
+	// The shallower name field dominates the embedded one, Shared conflicts
+	// at the same depth and is dropped and the tagged Tagged field wins over
+	// the untagged one.
+	require.Equal(t, []string{"Tagged", "name"}, keys)
+	require.Equal(t, []int{1, 1}, fields[0].index)
+	require.True(t, fields[0].embeddedPtr)
+	require.Equal(t, []int{2}, fields[1].index)
+	require.False(t, fields[1].embeddedPtr)
+
+	v := fieldsTestOuter{fieldsTestOther: &fieldsTestOther{Shared: "x", Tagged: "y"}, Name: "z"}
+	v.fieldsTestInner = fieldsTestInner{Name: "a", Shared: "b", Tagged: "c"}
+
+	out, err := FormatString(v, &Config{Format: "json"})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"Tagged":"y","name":"z"}`, out)
+}
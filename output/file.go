@@ -0,0 +1,107 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is the mode of files created by WriteFile.
+const defaultFileMode os.FileMode = 0644
+
+// WriteFile formats v using the given config and atomically writes the
+// result to the file name. If config.Format is empty, the format is inferred
+// from the file extension of name via Registry.LookupExtension, e.g. yaml for
+// `state.yml`.
+//
+// The output is written to a temporary file in the same directory which is
+// renamed to name afterwards, so that name either contains the previous or
+// the new content, but never partially written output. If name already
+// exists, its file mode is preserved, otherwise the file is created with mode
+// 0644. If name is a symlink, the file it points to is replaced.
+//
+// Colors are disabled unless config.Color is ColorAlways.
+func WriteFile(name string, v interface{}, config *Config) error {
+	if config.Format == "" {
+		format, err := formatForFile(name, config)
+		if err != nil {
+			return err
+		}
+
+		c := *config
+		c.Format = format
+		config = &c
+	}
+
+	buf, err := FormatBytes(v, resolveColor(io.Discard, config))
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(name, buf)
+}
+
+// formatForFile returns the name of the format for the file extension of
+// name.
+func formatForFile(name string, config *Config) (string, error) {
+	ext := filepath.Ext(name)
+	if ext == "" {
+		return "", fmt.Errorf("cannot infer output format of %q: file has no extension", name)
+	}
+
+	format, ok := configRegistry(config).LookupExtension(ext)
+	if !ok {
+		return "", fmt.Errorf("cannot infer output format of %q: no format for file extension %q", name, ext)
+	}
+
+	return format, nil
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to name.
+func writeFileAtomic(name string, data []byte) (err error) {
+	if target, err := filepath.EvalSymlinks(name); err == nil {
+		name = target
+	}
+
+	mode := defaultFileMode
+
+	fi, err := os.Stat(name)
+	switch {
+	case err == nil:
+		mode = fi.Mode().Perm()
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+
+	if err = f.Chmod(mode); err != nil {
+		return err
+	}
+
+	// Ensure that the data is on disk before the rename makes it visible.
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFile(t *testing.T) {
+	v := map[string]interface{}{"foo": "bar"}
+
+	tests := []struct {
+		name string
+		file string
+		cfg  Config
+		want string
+		err  error
+	}{
+		{
+			name: "infers format from extension",
+			file: "out.YML",
+			want: "foo: bar\n",
+		},
+		{
+			name: "explicit format",
+			file: "out.yaml",
+			cfg:  Config{Format: "json", TrailingNewline: true},
+			want: "{\n  \"foo\": \"bar\"\n}\n",
+		},
+		{
+			name: "no extension",
+			file: "out",
+			err:  errors.New(`cannot infer output format of "out": file has no extension`),
+		},
+		{
+			name: "unknown extension",
+			file: "out.foo",
+			err:  errors.New(`cannot infer output format of "out.foo": no format for file extension ".foo"`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			wd, err := os.Getwd()
+			require.NoError(t, err)
+			require.NoError(t, os.Chdir(dir))
+			defer os.Chdir(wd)
+
+			err = WriteFile(test.file, v, &test.cfg)
+			if test.err != nil {
+				require.EqualError(t, err, test.err.Error())
+				requireDirEntries(t, dir)
+				return
+			}
+
+			require.NoError(t, err)
+
+			buf, err := os.ReadFile(test.file)
+			require.NoError(t, err)
+			require.Equal(t, test.want, string(buf))
+
+			fi, err := os.Stat(test.file)
+			require.NoError(t, err)
+			require.Equal(t, defaultFileMode, fi.Mode().Perm()&defaultFileMode)
+			requireDirEntries(t, dir, test.file)
+		})
+	}
+}
+
+func TestWriteFile_existing(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "state.json")
+
+	require.NoError(t, os.WriteFile(name, []byte("old"), 0600))
+	require.NoError(t, os.Chmod(name, 0600))
+
+	t.Run("preserves mode", func(t *testing.T) {
+		require.NoError(t, WriteFile(name, []int{1}, &Config{Format: "json"}))
+
+		buf, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.Equal(t, "[\n  1\n]", string(buf))
+
+		fi, err := os.Stat(name)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	})
+
+	t.Run("keeps old content on error", func(t *testing.T) {
+		err := WriteFile(name, func() {}, &Config{})
+		require.EqualError(t, err, "json: unsupported type: func()")
+
+		buf, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.Equal(t, "[\n  1\n]", string(buf))
+		requireDirEntries(t, dir, "state.json")
+	})
+
+	t.Run("replaces symlink target", func(t *testing.T) {
+		link := filepath.Join(dir, "link.json")
+		require.NoError(t, os.Symlink(name, link))
+
+		require.NoError(t, WriteFile(link, []int{2}, &Config{Format: "json"}))
+
+		buf, err := os.ReadFile(name)
+		require.NoError(t, err)
+		require.Equal(t, "[\n  2\n]", string(buf))
+
+		fi, err := os.Lstat(link)
+		require.NoError(t, err)
+		require.NotZero(t, fi.Mode()&os.ModeSymlink)
+	})
+}
+
+// requireDirEntries asserts that dir contains exactly the files with the
+// given names, which ensures that no temporary files are left behind.
+func requireDirEntries(t *testing.T, dir string, names ...string) {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+
+	if names == nil {
+		names = []string{}
+	}
+
+	require.ElementsMatch(t, names, got)
+}
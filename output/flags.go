@@ -0,0 +1,234 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/martinohmann/exp/pflagx"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// AddFlags registers command line flags on fs which populate config. The
+// current values of config are used as defaults. Config.Registry should be
+// set before calling AddFlags as its formats are listed in the usage of the
+// --output flag.
+//
+// The --output flag is validated against the registry of config when it is
+// set, aliases and inline templates like `custom-columns=NAME:/name` are
+// accepted. Use ValidateFlags after parsing to reject flags which are not
+// supported by the chosen format.
+//
+// Only the --output, --template and --jsonpointer flags have shorthands to
+// keep conflicts with the flags of the calling command unlikely.
+//
+// The following flags are registered:
+//
+//   -o, --output         output format
+//   -t, --template       output template
+//       --template-file  glob patterns of template files
+//       --items          apply the template to slice items
+//   -j, --jsonpointer    json pointer for selecting data
+//       --query          jsonpath query for selecting data
+//       --filter         filter expression for slice items
+//       --sort-by        json pointer of the key to sort slice items by
+//       --sort-desc      sort in descending order
+//       --select         json pointers of fields to retain
+//       --uniq           remove duplicate slice items
+//       --offset         number of slice items to skip
+//       --limit          maximum number of slice items
+//       --columns        columns of table-like formats
+//       --wide           include wide columns
+//       --color          colorize output
+//       --format-opt     formatter options as key=value pairs
+//       --newline        ensure a trailing newline
+func AddFlags(fs *pflag.FlagSet, config *Config) {
+	fs.StringVarP(&config.Format, "output", "o", config.Format, "output format. one of "+configRegistry(config).Usage())
+	fs.StringVarP(&config.Template, "template", "t", config.Template, "output template. prefix with '@' to read it from a file. only supported by template-based output formats like 'gotemplate'")
+	fs.StringSliceVar(&config.TemplateFiles, "template-file", config.TemplateFiles, "glob patterns of template files containing named templates. only supported by template-based output formats like 'gotemplate'")
+	fs.BoolVar(&config.TemplateItems, "items", config.TemplateItems, "if true, the template applies to the items if the input is a slice. only supported by template-based output formats like 'gotemplate'")
+	fs.StringVarP(&config.JSONPointer, "jsonpointer", "j", config.JSONPointer, "json pointer for filtering the data before formatting, e.g. '/foo/0/bar'")
+	fs.StringVar(&config.Query, "query", config.Query, "jsonpath query for selecting data before formatting, e.g. '$.items[?(@.count > 1)].name'")
+	fs.StringVar(&config.Filter, "filter", config.Filter, "jsonpath filter expression for slice items, e.g. '@.count > 1'. '@' refers to the item")
+	fs.StringVar(&config.SortBy, "sort-by", config.SortBy, "json pointer of the key to sort slice items by, e.g. '/metadata/name'")
+	fs.BoolVar(&config.SortDescending, "sort-desc", config.SortDescending, "sort slice items in descending order. requires --sort-by")
+	fs.StringSliceVar(&config.Select, "select", config.Select, "json pointers of the fields to retain in each slice item, e.g. 'name,metadata/labels'")
+	fs.BoolVar(&config.Unique, "uniq", config.Unique, "remove duplicate slice items")
+	fs.IntVar(&config.Offset, "offset", config.Offset, "number of slice items to skip")
+	fs.IntVar(&config.Limit, "limit", config.Limit, "maximum number of slice items. 0 means no limit")
+	fs.StringSliceVar(&config.Columns, "columns", config.Columns, "columns to include in the output. ignored unless output format is 'table', 'csv' or 'tsv'")
+	fs.BoolVar(&config.Wide, "wide", config.Wide, "include wide columns. ignored unless output format is 'table', 'csv' or 'tsv'")
+	fs.Var(&config.Color, "color", "colorize json and yaml output. one of 'auto', 'always' or 'never'")
+	fs.Var((*formatOptionsValue)(&config.FormatOptions), "format-opt", "formatter specific options as key=value pairs, e.g. 'indent=4' for json")
+	fs.BoolVar(&config.TrailingNewline, "newline", config.TrailingNewline, "ensure output ends with a trailing newline")
+
+	pflagx.RegisterValidatorFunc(fs, "output", func(val string) error {
+		name, _, _ := splitFormat(val)
+
+		// The registry is resolved lazily to pick up formatters which are
+		// registered after the flags were added.
+		registry := configRegistry(config)
+		if _, ok := registry.Lookup(name); ok {
+			return nil
+		}
+
+		return pflagx.AnyOf(registry.Names()...)(name)
+	})
+}
+
+// AddFileFlag registers the --output-file flag on fs which sets name. It is
+// meant to be used alongside AddFlags to write output to name via WriteFile.
+// If --output-file is set but --output is not, ValidateFlags infers the
+// output format from the file extension.
+func AddFileFlag(fs *pflag.FlagSet, name *string) {
+	fs.StringVar(name, "output-file", *name, "write output atomically to this file instead of stdout. the output format is inferred from the file extension unless --output is set")
+}
+
+// ValidateFlags validates the flags registered by AddFlags after fs was
+// parsed. Returns an error if template flags were explicitly set but the
+// format chosen via config.Format does not use a template according to its
+// Metadata.
+//
+// If the --output-file flag registered by AddFileFlag was set but --output
+// was not, config.Format is set to the format inferred from the file
+// extension. Returns an error if there is no format for the extension.
+func ValidateFlags(fs *pflag.FlagSet, config *Config) error {
+	if flag := fs.Lookup("output-file"); flag != nil && flag.Changed && !fs.Changed("output") {
+		format, err := formatForFile(flag.Value.String(), config)
+		if err != nil {
+			return err
+		}
+
+		config.Format = format
+	}
+
+	name, _, _ := splitFormat(config.Format)
+
+	meta, ok := configRegistry(config).Metadata(name)
+	if !ok || meta.NeedsTemplate {
+		// Unknown formats are reported by the formatting funcs.
+		return nil
+	}
+
+	for _, flag := range []string{"template", "template-file", "items"} {
+		if fs.Changed(flag) {
+			return fmt.Errorf("--%s is not supported by output format %q", flag, name)
+		}
+	}
+
+	return nil
+}
+
+// AddCommandFlags registers the flags of AddFlags on cmd and adds shell
+// completion for the --output and --color flags. Additionally, a PreRunE
+// hook is installed on cmd which runs ValidateFlags before any existing
+// PreRunE or PreRun hook.
+func AddCommandFlags(cmd *cobra.Command, config *Config) {
+	AddFlags(cmd.Flags(), config)
+
+	_ = cmd.RegisterFlagCompletionFunc("output", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return formatCompletions(configRegistry(config)), cobra.ShellCompDirectiveNoFileComp
+	})
+
+	_ = cmd.RegisterFlagCompletionFunc("color", func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return []string{"auto", "always", "never"}, cobra.ShellCompDirectiveNoFileComp
+	})
+
+	preRunE, preRun := cmd.PreRunE, cmd.PreRun
+
+	// Cobra ignores cmd.PreRun if cmd.PreRunE is set, so it is invoked from
+	// the hook instead.
+	cmd.PreRunE = func(cmd *cobra.Command, args []string) error {
+		if err := ValidateFlags(cmd.Flags(), config); err != nil {
+			return err
+		}
+
+		if preRunE != nil {
+			return preRunE(cmd, args)
+		}
+
+		if preRun != nil {
+			preRun(cmd, args)
+		}
+
+		return nil
+	}
+}
+
+// formatCompletions returns shell completions for all formats and aliases in
+// registry. Completions include the format description if there is one.
+func formatCompletions(registry *Registry) []string {
+	var completions []string
+
+	for _, name := range registry.Names() {
+		completions = append(completions, completion(name, registry))
+	}
+
+	aliases := registry.Aliases()
+
+	names := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		names = append(names, alias)
+	}
+
+	sort.Strings(names)
+
+	for _, alias := range names {
+		completions = append(completions, completion(alias, registry))
+	}
+
+	return completions
+}
+
+func completion(name string, registry *Registry) string {
+	if meta, _ := registry.Metadata(name); meta.Description != "" {
+		return name + "\t" + meta.Description
+	}
+
+	return name
+}
+
+// formatOptionsValue is a pflag.Value which collects key=value pairs into
+// OptionValues. Values are kept as strings and parsed into the option types
+// declared by the formatter when formatting.
+type formatOptionsValue OptionValues
+
+// Set implements pflag.Value. Multiple pairs can be separated by commas.
+func (v *formatOptionsValue) Set(s string) error {
+	if *v == nil {
+		*v = make(formatOptionsValue)
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("%q must be formatted as key=value", pair)
+		}
+
+		(*v)[kv[0]] = kv[1]
+	}
+
+	return nil
+}
+
+// String implements pflag.Value.
+func (v *formatOptionsValue) String() string {
+	if len(*v) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(*v))
+	for k, val := range *v {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", k, val))
+	}
+
+	sort.Strings(pairs)
+
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+// Type implements pflag.Value.
+func (v *formatOptionsValue) Type() string {
+	return "key=value"
+}
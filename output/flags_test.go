@@ -0,0 +1,252 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		args   []string
+		want   Config
+		err    error
+	}{
+		{
+			name:   "defaults",
+			config: Config{Format: "json", TrailingNewline: true},
+			want:   Config{Format: "json", TrailingNewline: true},
+		},
+		{
+			name: "all flags",
+			args: []string{
+				"-o", "gotemplate", "-t", "{{.}}", "--template-file", "*.tmpl", "--items",
+				"-j", "/foo", "--query", "$.bar", "--columns", "a,b", "--wide", "--color", "never",
+				"--format-opt", "indent=4,compact=true", "--format-opt", "sort-keys=true", "--newline",
+				"--filter", "@.a > 1", "--sort-by", "/a", "--sort-desc", "--select", "a,b/c", "--uniq",
+				"--offset", "1", "--limit", "2",
+			},
+			want: Config{
+				Format:          "gotemplate",
+				Template:        "{{.}}",
+				TemplateFiles:   []string{"*.tmpl"},
+				TemplateItems:   true,
+				JSONPointer:     "/foo",
+				Query:           "$.bar",
+				Columns:         []string{"a", "b"},
+				Wide:            true,
+				Color:           ColorNever,
+				FormatOptions:   OptionValues{"indent": "4", "compact": "true", "sort-keys": "true"},
+				TrailingNewline: true,
+				Filter:          "@.a > 1",
+				SortBy:          "/a",
+				SortDescending:  true,
+				Select:          []string{"a", "b/c"},
+				Unique:          true,
+				Offset:          1,
+				Limit:           2,
+			},
+		},
+		{
+			name: "alias",
+			args: []string{"-o", "yml"},
+			want: Config{Format: "yml"},
+		},
+		{
+			name: "inline template",
+			args: []string{"-o", "custom-columns=NAME:/name"},
+			want: Config{Format: "custom-columns=NAME:/name"},
+		},
+		{
+			name: "unknown format",
+			args: []string{"-o", "foo=bar"},
+			err:  errors.New(`invalid argument "foo=bar" for "-o, --output" flag: possible values: "csv", "custom-columns", "custom-columns-file", "env", "export", "gostring", "gotemplate", "hcl", "html", "json", "json-lines", "jsonschema", "markdown", "properties", "table", "toml", "tree", "tsv", "xml", "yaml"`),
+		},
+		{
+			name: "custom registry",
+			config: Config{
+				Registry: func() *Registry {
+					r := NewRegistry(nil)
+					r.RegisterFormatFunc("foo", formatJSON)
+					return r
+				}(),
+			},
+			args: []string{"-o", "json"},
+			err:  errors.New(`invalid argument "json" for "-o, --output" flag: possible values: "foo"`),
+		},
+		{
+			name: "invalid format option",
+			args: []string{"--format-opt", "indent"},
+			err:  errors.New(`invalid argument "indent" for "--format-opt" flag: "indent" must be formatted as key=value`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+			config := test.config
+
+			AddFlags(fs, &config)
+
+			err := fs.Parse(test.args)
+			if test.err != nil {
+				require.EqualError(t, err, test.err.Error())
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.want, config)
+			}
+		})
+	}
+}
+
+func TestAddFlags_shorthandConflicts(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	quiet := fs.BoolP("quiet", "q", false, "")
+	overwrite := fs.BoolP("overwrite", "O", false, "")
+
+	var config Config
+
+	require.NotPanics(t, func() { AddFlags(fs, &config) })
+	require.NoError(t, fs.Parse([]string{"-q", "-O", "--query", "$.foo"}))
+	require.True(t, *quiet)
+	require.True(t, *overwrite)
+	require.Equal(t, "$.foo", config.Query)
+}
+
+func TestValidateFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		args   []string
+		want   string
+		err    error
+	}{
+		{
+			name:   "template with gotemplate",
+			config: Config{Format: "json"},
+			args:   []string{"-o", "gotemplate", "-t", "{{.}}"},
+		},
+		{
+			name:   "template set via config",
+			config: Config{Format: "json", Template: "{{.}}"},
+		},
+		{
+			name:   "template with json",
+			config: Config{Format: "json"},
+			args:   []string{"-t", "{{.}}"},
+			err:    errors.New(`--template is not supported by output format "json"`),
+		},
+		{
+			name:   "items with alias",
+			config: Config{Format: "json"},
+			args:   []string{"-o", "yml", "--items"},
+			err:    errors.New(`--items is not supported by output format "yml"`),
+		},
+		{
+			name:   "infers format from output file",
+			config: Config{Format: "json"},
+			args:   []string{"--output-file", "out.yml"},
+			want:   "yaml",
+		},
+		{
+			name:   "explicit format with output file",
+			config: Config{Format: "json"},
+			args:   []string{"--output-file", "out.yml", "-o", "toml"},
+			want:   "toml",
+		},
+		{
+			name:   "output file with unknown extension",
+			config: Config{Format: "json"},
+			args:   []string{"--output-file", "out.foo"},
+			err:    errors.New(`cannot infer output format of "out.foo": no format for file extension ".foo"`),
+		},
+		{
+			name:   "template with inferred format",
+			config: Config{Format: "gotemplate"},
+			args:   []string{"--output-file", "out.json", "-t", "{{.}}"},
+			err:    errors.New(`--template is not supported by output format "json"`),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+
+			config := test.config
+
+			var outputFile string
+
+			AddFlags(fs, &config)
+			AddFileFlag(fs, &outputFile)
+
+			require.NoError(t, fs.Parse(test.args))
+
+			err := ValidateFlags(fs, &config)
+			if test.err != nil {
+				require.EqualError(t, err, test.err.Error())
+			} else {
+				require.NoError(t, err)
+
+				if test.want != "" {
+					require.Equal(t, test.want, config.Format)
+				}
+			}
+		})
+	}
+}
+
+func TestAddCommandFlags(t *testing.T) {
+	t.Run("validates flags before PreRun", func(t *testing.T) {
+		var preRun bool
+
+		cmd := &cobra.Command{
+			Use:    "test",
+			PreRun: func(cmd *cobra.Command, args []string) { preRun = true },
+			Run:    func(cmd *cobra.Command, args []string) {},
+		}
+
+		AddCommandFlags(cmd, &Config{Format: "json"})
+
+		cmd.SetArgs([]string{"-t", "{{.}}"})
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+
+		require.EqualError(t, cmd.Execute(), `--template is not supported by output format "json"`)
+		require.False(t, preRun)
+
+		cmd.SetArgs([]string{"-o", "gotemplate", "-t", "{{.}}"})
+		require.NoError(t, cmd.Execute())
+		require.True(t, preRun)
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		registry := NewRegistry(nil)
+		registry.RegisterFormatter("foo", &builtinFormatter{
+			Formatter: FormatFunc(formatJSON),
+			metadata:  Metadata{Description: "foo format"},
+		})
+		registry.RegisterFormatFunc("bar", formatJSON)
+		registry.RegisterAlias("baz", "foo")
+
+		cmd := &cobra.Command{Use: "test", Run: func(cmd *cobra.Command, args []string) {}}
+
+		AddCommandFlags(cmd, &Config{Registry: registry})
+
+		var buf bytes.Buffer
+
+		cmd.SetOut(&buf)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{cobra.ShellCompRequestCmd, "--output", ""})
+
+		require.NoError(t, cmd.Execute())
+		require.Equal(t, "bar\nfoo\tfoo format\nbaz\tfoo format\n:4\n", buf.String())
+	})
+}
@@ -0,0 +1,239 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var flattenOptions = []Option{
+	{Name: "prefix", Type: StringOption, Default: "", Usage: "prefix prepended to all keys"},
+	{Name: "join-arrays", Type: BoolOption, Default: false, Usage: "join arrays of scalars with commas instead of flattening them into indexed keys"},
+}
+
+// flatEntry is a leaf value with the path of keys leading to it.
+type flatEntry struct {
+	path  []string
+	value string
+}
+
+// flatten flattens v into a list of leaf values. Objects are flattened in
+// sorted key order, array items use their index as key. Arrays of scalars are
+// joined with commas instead if joinArrays is true, which is the format
+// slice flags of pflag expect. Empty objects and arrays do not produce any
+// entries.
+func flatten(v interface{}, config *Config, format string) ([]flatEntry, error) {
+	opts := optionValues(flattenOptions, config)
+
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var path []string
+
+	if prefix := opts.String("prefix"); prefix != "" {
+		path = []string{prefix}
+	} else if isScalar(nv) {
+		return nil, fmt.Errorf("%s: top-level value must be an object or array unless the prefix option is set, got %T", format, v)
+	}
+
+	return appendFlatEntries(nil, path, nv, opts.Bool("join-arrays")), nil
+}
+
+func appendFlatEntries(entries []flatEntry, path []string, v interface{}, joinArrays bool) []flatEntry {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			entries = appendFlatEntries(entries, appendPath(path, key), v[key], joinArrays)
+		}
+	case []interface{}:
+		if joinArrays && isScalarSlice(v) {
+			values := make([]string, len(v))
+			for i, item := range v {
+				values[i] = stringify(item)
+			}
+
+			return append(entries, flatEntry{path: path, value: strings.Join(values, ",")})
+		}
+
+		for i, item := range v {
+			entries = appendFlatEntries(entries, appendPath(path, strconv.Itoa(i)), item, joinArrays)
+		}
+	default:
+		entries = append(entries, flatEntry{path: path, value: stringify(v)})
+	}
+
+	return entries
+}
+
+// appendPath returns a copy of path with key appended, so that sibling paths
+// do not share their backing array.
+func appendPath(path []string, key string) []string {
+	p := make([]string, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, key)
+}
+
+func isScalarSlice(s []interface{}) bool {
+	for _, v := range s {
+		if !isScalar(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isScalar returns true if the normalized value v is neither an object nor an
+// array.
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return false
+	default:
+		return true
+	}
+}
+
+// formatProperties renders v in the Java properties format with keys joined
+// by dots, e.g. `a.b.c=value`.
+func formatProperties(v interface{}, config *Config) ([]byte, error) {
+	entries, err := flatten(v, config, "properties")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	for _, entry := range entries {
+		buf.WriteString(escapeProperty(strings.Join(entry.path, "."), true))
+		buf.WriteByte('=')
+		buf.WriteString(escapeProperty(entry.value, false))
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// escapeProperty escapes s for use as key or value in a properties file.
+// Separators and comment characters only need to be escaped in keys, leading
+// whitespace only in values.
+func escapeProperty(s string, key bool) string {
+	var sb strings.Builder
+
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\r':
+			sb.WriteString(`\r`)
+		case r == '\t':
+			sb.WriteString(`\t`)
+		case r == '\f':
+			sb.WriteString(`\f`)
+		case r < 0x20 || r == 0x7f || r == utf8.RuneError:
+			fmt.Fprintf(&sb, `\u%04x`, r)
+		case key && strings.ContainsRune(" =:#!", r):
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case !key && i == 0 && (r == ' ' || r == '#' || r == '!'):
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
+
+// formatEnv renders v as environment variable assignments with keys joined
+// by underscores, e.g. `A_B_C=value`. This is the inverse of how
+// pflagx.BindViper maps nested config keys to environment variables.
+func formatEnv(v interface{}, config *Config) ([]byte, error) {
+	return formatEnvAssignments(v, config, "env", "")
+}
+
+// formatExport is like formatEnv, but prefixes every assignment with
+// `export`, so that the output can be evaluated by a shell.
+func formatExport(v interface{}, config *Config) ([]byte, error) {
+	return formatEnvAssignments(v, config, "export", "export ")
+}
+
+func formatEnvAssignments(v interface{}, config *Config, format, prefix string) ([]byte, error) {
+	entries, err := flatten(v, config, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	paths := make(map[string]string, len(entries))
+
+	for _, entry := range entries {
+		path := strings.Join(entry.path, ".")
+		name := envName(entry.path)
+
+		if other, ok := paths[name]; ok {
+			return nil, fmt.Errorf("%s: keys %q and %q both map to variable %s", format, other, path, name)
+		}
+
+		paths[name] = path
+
+		buf.WriteString(prefix)
+		buf.WriteString(name)
+		buf.WriteByte('=')
+		buf.WriteString(shellQuote(entry.value))
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// envName builds the name of an environment variable from path. Keys are
+// joined with underscores and uppercased, characters which are not allowed in
+// variable names are replaced with underscores.
+func envName(path []string) string {
+	name := []rune(strings.ToUpper(strings.Join(path, "_")))
+
+	for i, r := range name {
+		if !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			name[i] = '_'
+		}
+	}
+
+	if len(name) == 0 || name[0] >= '0' && name[0] <= '9' {
+		return "_" + string(name)
+	}
+
+	return string(name)
+}
+
+// shellQuote quotes s for safe use in a POSIX shell. Strings which only
+// consist of safe characters are returned as is, all others are enclosed in
+// single quotes.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+
+	safe := true
+
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("@%+=:,./_-", r)) {
+			safe = false
+			break
+		}
+	}
+
+	if safe {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatFlattened(t *testing.T) {
+	v := map[string]interface{}{
+		"log-level": "debug",
+		"server": map[string]interface{}{
+			"addr":    ":8080",
+			"tls":     map[string]interface{}{"enabled": true},
+			"origins": []interface{}{"a.example.com", "b.example.com"},
+			"timeout": nil,
+		},
+		"greeting": "it's a \"nice\" day\n",
+		"empty":    map[string]interface{}{},
+	}
+
+	tests := []formatTestCase{
+		{
+			name: "properties",
+			cfg:  Config{Format: "properties"},
+			v:    v,
+			want: `greeting=it's a "nice" day\n
+log-level=debug
+server.addr=:8080
+server.origins.0=a.example.com
+server.origins.1=b.example.com
+server.timeout=
+server.tls.enabled=true
+`,
+		},
+		{
+			name: "properties escapes keys and values",
+			cfg:  Config{Format: "properties"},
+			v:    map[string]interface{}{"a key=:": " #value\\", "b": "#"},
+			want: "a\\ key\\=\\:=\\ #value\\\\\nb=\\#\n",
+		},
+		{
+			name: "env",
+			cfg:  Config{Format: "env"},
+			v:    v,
+			want: `GREETING='it'\''s a "nice" day
+'
+LOG_LEVEL=debug
+SERVER_ADDR=:8080
+SERVER_ORIGINS_0=a.example.com
+SERVER_ORIGINS_1=b.example.com
+SERVER_TIMEOUT=''
+SERVER_TLS_ENABLED=true
+`,
+		},
+		{
+			name: "export with prefix and joined arrays",
+			cfg:  Config{Format: "export", FormatOptions: OptionValues{"prefix": "app", "join-arrays": true}},
+			v:    map[string]interface{}{"server": v["server"]},
+			want: `export APP_SERVER_ADDR=:8080
+export APP_SERVER_ORIGINS=a.example.com,b.example.com
+export APP_SERVER_TIMEOUT=''
+export APP_SERVER_TLS_ENABLED=true
+`,
+		},
+		{
+			name: "top-level array",
+			cfg:  Config{Format: "env"},
+			v:    []interface{}{map[string]interface{}{"name": "foo bar"}},
+			want: "_0_NAME='foo bar'\n",
+		},
+		{
+			name: "scalar with prefix",
+			cfg:  Config{Format: "env", FormatOptions: OptionValues{"prefix": "name"}},
+			v:    "foo",
+			want: "NAME=foo\n",
+		},
+		{
+			name: "scalar without prefix",
+			cfg:  Config{Format: "properties"},
+			v:    "foo",
+			err:  errors.New("properties: top-level value must be an object or array unless the prefix option is set, got string"),
+		},
+		{
+			name: "conflicting variable names",
+			cfg:  Config{Format: "env"},
+			v:    map[string]interface{}{"a": map[string]interface{}{"b": 1}, "a-b": 2},
+			err:  errors.New(`env: keys "a.b" and "a-b" both map to variable A_B`),
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
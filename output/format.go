@@ -3,20 +3,48 @@ package output
 import (
 	"fmt"
 	"io"
+	"io/fs"
+	"strings"
 	"text/template"
-
-	"github.com/mitchellh/pointerstructure"
 )
 
 // Config configures the behaviour of the output formatter.
 type Config struct {
-	// Format must contain the name of the formatter that should be used.
+	// Format must contain the name of the formatter that should be used. The
+	// name may optionally be followed by an equals sign and a template, e.g.
+	// `custom-columns=NAME:/name`. In this case the template overrides the
+	// Template field.
 	Format string
-	// Formatters can be set to configure user-defined formatters. If empty,
-	// the built-in formatters are used.
+	// Registry optionally configures the registry to look up formatters in.
+	// If nil, the DefaultRegistry is used. Use NewRegistry(DefaultRegistry)
+	// to add custom formatters while retaining the built-in ones.
+	Registry *Registry
+	// Formatters can be set to configure user-defined formatters. If
+	// non-empty, formatters are only looked up in this map and Registry is
+	// ignored.
+	//
+	// Deprecated: use Registry instead, which is safe for concurrent use and
+	// supports aliases.
 	Formatters FormatterMap
-	// Template configures the template for template-based formatters.
+	// Template configures the template for template-based formatters. If
+	// the template starts with an @, the remainder is treated as the path of
+	// a file to read the template from, e.g. `@path/to/report.tmpl`.
 	Template string
+	// TemplateFiles optionally configures glob patterns of template files
+	// which are parsed alongside Template. This can be used to define named
+	// templates that can be invoked as partials via
+	// `{{template "name" .}}`. If Template is empty, the first file matched
+	// by the first pattern is executed unless TemplateName is set.
+	TemplateFiles []string
+	// TemplateName optionally configures the name of the template that
+	// should be executed. Files parsed from TemplateFiles are named after
+	// their base name, named templates after their definition. Defaults to
+	// Template if it is non-empty.
+	TemplateName string
+	// TemplateFS optionally configures a filesystem from which template
+	// files and templates referenced via @path are read, e.g. an embed.FS.
+	// If nil, templates are read from the OS filesystem.
+	TemplateFS fs.FS
 	// TemplateConfig is optional configuration for the underlying template
 	// struct for template-based formatter.
 	// See: https://golang.org/pkg/text/template/#Template
@@ -37,67 +65,176 @@ type Config struct {
 	// the original object is passed as is.
 	// See RFC: https://datatracker.ietf.org/doc/html/rfc6901
 	JSONPointer string
+	// Columns optionally selects the columns that should be rendered by
+	// table-based formatters. Columns are matched case-insensitively against
+	// the keys of maps and the json field names of structs and are rendered
+	// in the order they are provided. If empty, all columns are rendered.
+	Columns []string
+	// Query can be filled with a JSONPath expression to select values from
+	// an object before passing them on to the formatter. It is evaluated
+	// after JSONPointer. If the expression can match at most one value, the
+	// matching value is passed to the formatter, otherwise a slice of all
+	// matching values. If empty the object is passed as is.
+	// See package github.com/martinohmann/exp/jsonpath for the supported
+	// syntax.
+	Query string
+	// Color controls colorized output of formatters that support it, e.g.
+	// json and yaml. Defaults to ColorAuto, which enables colors if the
+	// output is written to a terminal and the NO_COLOR environment variable
+	// is not set.
+	Color ColorMode
+	// Wide includes the columns of struct fields tagged with
+	// `output:",wide"` in table-like formats.
+	Wide bool
+	// FormatOptions configures options of formatters that implement
+	// ConfigurableFormatter, e.g. the indent of the json formatter. Values
+	// must either match the declared type of the option or be strings that
+	// can be parsed into it. Options which are not declared by the formatter
+	// cause an error.
+	FormatOptions OptionValues
+	// Filter optionally configures a JSONPath filter expression like
+	// `@.count > 1 && @.name =~ /^foo/` which is matched against each item
+	// of the selected slice. Items that do not match are dropped. Within the
+	// expression `@` refers to the item.
+	Filter string
+	// SortBy optionally configures a JSON pointer like `/metadata/name`
+	// which selects the key to sort the items of the selected slice by. The
+	// leading slash may be omitted. Items without the key sort before all
+	// other items in ascending order. The sort is stable.
+	SortBy string
+	// SortDescending reverses the sort order of SortBy.
+	SortDescending bool
+	// Select optionally configures JSON pointers of fields which should be
+	// retained in each item of the selected slice or in the selected value
+	// if it is not a slice. The leading slash may be omitted. All other
+	// fields are dropped while the nesting of the selected fields is
+	// preserved. Unless Columns is set, table-based formatters render the
+	// selected fields in the order they are provided.
+	Select []string
+	// Unique removes items from the selected slice which are equal to a
+	// previous item. It is applied after Select, so that items are
+	// deduplicated by the selected fields only.
+	Unique bool
+	// Offset skips the first items of the selected slice.
+	Offset int
+	// Limit limits the number of items of the selected slice if it is
+	// greater than zero.
+	Limit int
+
+	// templateCache is set by Compile to parse templates only once.
+	templateCache *templateCache
 }
 
 // TemplateConfig is optional configuration for the underlying template struct
 // for template-based formatter.
 // See: https://golang.org/pkg/text/template/#Template
 type TemplateConfig struct {
-	// Funcs configures additional template funcs.
+	// Funcs configures additional template funcs. Funcs take precedence over
+	// default funcs with the same name.
 	// See: https://golang.org/pkg/text/template/#Template.Funcs
 	Funcs template.FuncMap
+	// DefaultFuncs makes the built-in template funcs returned by
+	// DefaultTemplateFuncs available to templates if true.
+	DefaultFuncs bool
 	// Options configures additional template options.
 	// See: https://golang.org/pkg/text/template/#Template.Option
 	Options []string
+	// Strict makes template execution fail if a map has no entry for a key
+	// instead of printing "<no value>". This is equivalent to the
+	// "missingkey=error" option.
+	Strict bool
 }
 
 // Format formats v using the given config and writes the result to w. Returns
 // any error that may occur during formatting. On errors nothing is written to
-// w.
+// w. Use Compile when formatting many values with the same config.
 func Format(w io.Writer, v interface{}, config *Config) error {
-	buf, err := FormatBytes(v, config)
+	c, err := compile(config)
 	if err != nil {
 		return err
 	}
 
-	_, err = w.Write(buf)
-	return err
+	if c.config.Color == ColorAuto {
+		c.config.Color = ColorNever
+
+		if colorEnabled(w) {
+			c.config.Color = ColorAlways
+		}
+	}
+
+	return c.Format(w, v)
 }
 
 // FormatBytes formats v using the given config and returns the formatted
 // bytes. Returns any error that may occur during formatting.
 func FormatBytes(v interface{}, config *Config) ([]byte, error) {
-	formatters := config.Formatters
-	if len(formatters) == 0 {
-		formatters = DefaultFormatters
+	c, err := compile(config)
+	if err != nil {
+		return nil, err
 	}
 
-	f, ok := formatters[config.Format]
+	return c.FormatBytes(v)
+}
+
+// lookupFormatter looks up the formatter for config.Format. Returns the
+// formatter alongside a copy of config in which aliases in the Format field
+// are resolved to the canonical format name, the inline template of the
+// format (if any) overrides the Template field and all formatter options are
+// resolved.
+func lookupFormatter(config *Config) (Formatter, *Config, error) {
+	name, tpl, hasTemplate := splitFormat(config.Format)
+
+	canonical, f, ok := configRegistry(config).lookup(name)
 	if !ok {
-		return nil, fmt.Errorf("no formatter for format %q", config.Format)
+		return nil, nil, fmt.Errorf("no formatter for format %q", name)
 	}
 
-	if config.JSONPointer != "" {
-		pv, err := pointerstructure.Get(v, config.JSONPointer)
-		if err != nil {
-			return nil, err
+	name = canonical
+
+	options, err := resolveOptions(f, name, config.FormatOptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c := *config
+	c.Format = name
+	c.FormatOptions = options
+
+	if hasTemplate {
+		c.Template = tpl
+	}
+
+	return f, &c, nil
+}
+
+// configRegistry returns the registry to look up formatters in according to
+// config.
+func configRegistry(config *Config) *Registry {
+	if len(config.Formatters) > 0 {
+		r := NewRegistry(nil)
+		for name, f := range config.Formatters {
+			r.RegisterFormatter(name, f)
 		}
 
-		v = pv
+		return r
 	}
 
-	buf, err := f.Format(v, config)
-	if err != nil {
-		return nil, err
+	if config.Registry != nil {
+		return config.Registry
 	}
 
-	// Append a trailing newline if requested, but only if the formatter did
-	// not already do that for us.
-	if config.TrailingNewline && len(buf) > 0 && buf[len(buf)-1] != '\n' {
-		buf = append(buf, '\n')
+	return DefaultRegistry
+}
+
+// splitFormat splits format of the form <name>=<template> into its
+// components. The returned bool is true if format contained a template.
+func splitFormat(format string) (name string, template string, ok bool) {
+	parts := strings.SplitN(format, "=", 2)
+	if len(parts) == 1 {
+		return format, "", false
 	}
 
-	return buf, nil
+	return parts[0], parts[1], true
 }
 
 // FormatString formats v using the given config and returns the formatted
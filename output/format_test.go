@@ -46,6 +46,45 @@ func TestFormatString(t *testing.T) {
 			v:    map[string]interface{}{"foo": "bar"},
 			want: "\"bar\"",
 		},
+		{
+			name: "evaluates query",
+			cfg:  Config{Format: "json", Query: "$.items[?(@.count > 1)].name"},
+			v: map[string]interface{}{
+				"items": []map[string]interface{}{
+					{"name": "foo", "count": 1},
+					{"name": "bar", "count": 2},
+					{"name": "baz", "count": 3},
+				},
+			},
+			want: "[\n  \"bar\",\n  \"baz\"\n]",
+		},
+		{
+			name: "evaluates definite query",
+			cfg:  Config{Format: "json", Query: "items[1].name"},
+			v: map[string]interface{}{
+				"items": []map[string]interface{}{{"name": "foo"}, {"name": "bar"}},
+			},
+			want: "\"bar\"",
+		},
+		{
+			name: "evaluates query after json pointer",
+			cfg:  Config{Format: "json", JSONPointer: "/items", Query: "$[*].name"},
+			v: map[string]interface{}{
+				"items": []map[string]interface{}{{"name": "foo"}, {"name": "bar"}},
+			},
+			want: "[\n  \"foo\",\n  \"bar\"\n]",
+		},
+		{
+			name: "definite query without match",
+			cfg:  Config{Format: "json", Query: "$.foo.bar"},
+			v:    map[string]interface{}{"foo": "bar"},
+			err:  errors.New(`query "$.foo.bar" did not match any value`),
+		},
+		{
+			name: "invalid query",
+			cfg:  Config{Format: "json", Query: "$["},
+			err:  errors.New(`invalid jsonpath expression "$[": expected selector at end of input`),
+		},
 		{
 			name: "does not add trailing newline if there already is one",
 			cfg:  Config{Format: "yaml", TrailingNewline: true},
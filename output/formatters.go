@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/ghodss/yaml"
 )
@@ -67,43 +68,317 @@ func (m FormatterMap) DeepCopy() FormatterMap {
 }
 
 // DefaultFormatters is a map of built-in formatters which can be extended to
-// make more formatters globally available. These are used as a fallback if the
-// user does not provide a custom map as part of the config to Format* funcs.
+// make more formatters globally available. The DefaultRegistry looks up
+// formatters in this map before its own formatters, so changes to it are
+// picked up by the Format* funcs. It only contains the formatters that were
+// available before the DefaultRegistry was introduced.
+//
+// Deprecated: use DefaultRegistry and RegisterFormatter instead. Unlike the
+// DefaultRegistry, DefaultFormatters is not safe for concurrent use.
 var DefaultFormatters = FormatterMap{
-	"json": FormatFunc(func(v interface{}, config *Config) ([]byte, error) {
-		return json.MarshalIndent(v, "", "  ")
-	}),
-	"yaml": FormatFunc(func(v interface{}, config *Config) ([]byte, error) {
-		return yaml.Marshal(v)
-	}),
-	"gostring": FormatFunc(func(v interface{}, config *Config) ([]byte, error) {
-		return []byte(fmt.Sprintf("%#v", v)), nil
-	}),
-	"gotemplate": FormatFunc(func(v interface{}, config *Config) ([]byte, error) {
-		var buf bytes.Buffer
-
-		if err := formatTemplate(&buf, v, config); err != nil {
+	"json":       builtinFormatters["json"],
+	"yaml":       builtinFormatters["yaml"],
+	"gostring":   builtinFormatters["gostring"],
+	"gotemplate": builtinFormatters["gotemplate"],
+}
+
+// builtinFormatters contains the built-in formatters which are available via
+// the DefaultRegistry.
+var builtinFormatters = FormatterMap{
+	"json": &builtinFormatter{
+		Formatter: FormatFunc(formatJSON),
+		options:   jsonOptions,
+		metadata: Metadata{
+			Description: "JSON document",
+			MIMEType:    "application/json",
+			Extensions:  []string{".json"},
+		},
+	},
+	"yaml": &streamFormatter{
+		FormatFunc:     formatYAML,
+		newItemEncoder: newYAMLItemEncoder,
+		options:        yamlOptions,
+		metadata: Metadata{
+			Description: "YAML document",
+			MIMEType:    "application/yaml",
+			Extensions:  []string{".yaml", ".yml"},
+		},
+	},
+	"toml": &builtinFormatter{
+		Formatter: FormatFunc(formatTOML),
+		options:   tomlOptions,
+		metadata: Metadata{
+			Description: "TOML document",
+			MIMEType:    "application/toml",
+			Extensions:  []string{".toml"},
+		},
+	},
+	"xml": &builtinFormatter{
+		Formatter: FormatFunc(formatXML),
+		options:   xmlOptions,
+		metadata: Metadata{
+			Description: "XML document",
+			MIMEType:    "application/xml",
+			Extensions:  []string{".xml"},
+		},
+	},
+	"hcl": &builtinFormatter{
+		Formatter: FormatFunc(formatHCL),
+		metadata: Metadata{
+			Description: "HCL document",
+			Extensions:  []string{".hcl"},
+		},
+	},
+	"jsonschema": &builtinFormatter{
+		Formatter: FormatFunc(formatJSONSchema),
+		options:   jsonSchemaOptions,
+		metadata: Metadata{
+			Description: "JSON Schema of the JSON output",
+			MIMEType:    "application/schema+json",
+		},
+	},
+	"json-lines": &streamFormatter{
+		FormatFunc:     formatJSONLines,
+		newItemEncoder: newJSONLinesItemEncoder,
+		metadata: Metadata{
+			Description: "newline delimited JSON",
+			MIMEType:    "application/x-ndjson",
+			Extensions:  []string{".jsonl", ".ndjson"},
+		},
+	},
+	"gostring": &builtinFormatter{
+		Formatter: FormatFunc(func(v interface{}, config *Config) ([]byte, error) {
+			return []byte(fmt.Sprintf("%#v", v)), nil
+		}),
+		metadata: Metadata{
+			Description: "Go syntax representation",
+		},
+	},
+	"gotemplate": &streamFormatter{
+		FormatFunc: func(v interface{}, config *Config) ([]byte, error) {
+			var buf bytes.Buffer
+
+			if err := formatTemplate(&buf, v, config); err != nil {
+				return nil, err
+			}
+
+			return buf.Bytes(), nil
+		},
+		newItemEncoder: newTemplateItemEncoder,
+		metadata: Metadata{
+			Description:   "Go template",
+			MIMEType:      "text/plain",
+			NeedsTemplate: true,
+		},
+	},
+	"table": &builtinFormatter{
+		Formatter: FormatFunc(formatTable),
+		metadata: Metadata{
+			Description: "aligned columns",
+			MIMEType:    "text/plain",
+			Extensions:  []string{".txt"},
+		},
+	},
+	"tree": &builtinFormatter{
+		Formatter: FormatFunc(formatTree),
+		options:   treeOptions,
+		metadata: Metadata{
+			Description: "tree with box-drawing guides",
+			MIMEType:    "text/plain",
+		},
+	},
+	"properties": &builtinFormatter{
+		Formatter: FormatFunc(formatProperties),
+		options:   flattenOptions,
+		metadata: Metadata{
+			Description: "Java properties with dot-separated keys",
+			MIMEType:    "text/x-java-properties",
+			Extensions:  []string{".properties"},
+		},
+	},
+	"env": &builtinFormatter{
+		Formatter: FormatFunc(formatEnv),
+		options:   flattenOptions,
+		metadata: Metadata{
+			Description: "environment variables for .env files",
+			Extensions:  []string{".env"},
+		},
+	},
+	"export": &builtinFormatter{
+		Formatter: FormatFunc(formatExport),
+		options:   flattenOptions,
+		metadata: Metadata{
+			Description: "shell export statements",
+			Extensions:  []string{".sh"},
+		},
+	},
+	"markdown": &builtinFormatter{
+		Formatter: FormatFunc(formatMarkdown),
+		options:   markdownOptions,
+		metadata: Metadata{
+			Description: "markdown tables and lists",
+			MIMEType:    "text/markdown",
+			Extensions:  []string{".md", ".markdown"},
+		},
+	},
+	"html": &builtinFormatter{
+		Formatter: FormatFunc(formatHTML),
+		options:   htmlOptions,
+		metadata: Metadata{
+			Description: "HTML tables and lists",
+			MIMEType:    "text/html",
+			Extensions:  []string{".html", ".htm"},
+		},
+	},
+	"custom-columns": &builtinFormatter{
+		Formatter: FormatFunc(formatCustomColumns),
+		metadata: Metadata{
+			Description:   "aligned columns selected by JSON pointers",
+			MIMEType:      "text/plain",
+			NeedsTemplate: true,
+		},
+	},
+	"custom-columns-file": &builtinFormatter{
+		Formatter: FormatFunc(formatCustomColumnsFile),
+		metadata: Metadata{
+			Description:   "custom-columns read from a file",
+			MIMEType:      "text/plain",
+			NeedsTemplate: true,
+		},
+	},
+	"csv": &streamFormatter{
+		FormatFunc:     formatCSV,
+		newItemEncoder: newCSVItemEncoder,
+		metadata: Metadata{
+			Description: "comma-separated values",
+			MIMEType:    "text/csv",
+			Extensions:  []string{".csv"},
+		},
+	},
+	"tsv": &streamFormatter{
+		FormatFunc:     formatTSV,
+		newItemEncoder: newTSVItemEncoder,
+		metadata: Metadata{
+			Description: "tab-separated values",
+			MIMEType:    "text/tab-separated-values",
+			Extensions:  []string{".tsv"},
+		},
+	},
+}
+
+// builtinFormatter wraps a Formatter and adds option declarations and
+// metadata to it.
+type builtinFormatter struct {
+	Formatter
+	options  []Option
+	metadata Metadata
+}
+
+// Options implements the ConfigurableFormatter interface.
+func (f *builtinFormatter) Options() []Option {
+	return f.options
+}
+
+// Metadata implements the DescribedFormatter interface.
+func (f *builtinFormatter) Metadata() Metadata {
+	return f.metadata
+}
+
+var jsonOptions = []Option{
+	{Name: "indent", Type: IntOption, Default: 2, Usage: "number of spaces used for indentation", Min: intBound(0)},
+	{Name: "compact", Type: BoolOption, Default: false, Usage: "produce compact output without any whitespace"},
+	{Name: "sort-keys", Type: BoolOption, Default: false, Usage: "sort object keys, including struct fields"},
+	{Name: "escape-html", Type: BoolOption, Default: true, Usage: "escape <, > and & in strings"},
+}
+
+func formatJSON(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(jsonOptions, config)
+
+	if opts.Bool("sort-keys") {
+		// Normalization converts structs into maps, which are always
+		// encoded with sorted keys.
+		nv, err := normalize(v)
+		if err != nil {
 			return nil, err
 		}
 
-		return buf.Bytes(), nil
-	}),
+		v = nv
+	}
+
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(opts.Bool("escape-html"))
+
+	if !opts.Bool("compact") {
+		enc.SetIndent("", strings.Repeat(" ", opts.Int("indent")))
+	}
+
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	out := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+
+	if config.Color == ColorAlways {
+		out = colorizeJSON(out)
+	}
+
+	return out, nil
+}
+
+var yamlOptions = []Option{
+	{Name: "flow", Type: BoolOption, Default: false, Usage: "use flow style for collections, e.g. {a: [1, 2]}"},
+}
+
+func formatYAML(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(yamlOptions, config)
+
+	var (
+		buf []byte
+		err error
+	)
+
+	if opts.Bool("flow") {
+		buf, err = marshalYAMLFlow(v)
+	} else {
+		buf, err = yaml.Marshal(v)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The YAML colorizer only understands block style.
+	if config.Color == ColorAlways && !opts.Bool("flow") {
+		buf = colorizeYAML(buf)
+	}
+
+	return buf, nil
 }
 
-// RegisterFormatter globally registers a Formatter. Panics if a formatter with
-// the same name already exists.
+// RegisterFormatter globally registers a Formatter in the DefaultRegistry.
+// Panics if a formatter with the same name already exists.
 func RegisterFormatter(name string, f Formatter) {
-	DefaultFormatters.RegisterFormatter(name, f)
+	DefaultRegistry.RegisterFormatter(name, f)
 }
 
-// RegisterFormatFunc globally registers a FormatFunc. Panics if a formatter
-// with the same name already exists.
+// RegisterFormatFunc globally registers a FormatFunc in the DefaultRegistry.
+// Panics if a formatter with the same name already exists.
 func RegisterFormatFunc(name string, f FormatFunc) {
-	DefaultFormatters.RegisterFormatFunc(name, f)
+	DefaultRegistry.RegisterFormatFunc(name, f)
 }
 
 // FormatterNames returns a sorted slice of globally registered formatter
 // names. This is useful to present allowed values in command line flags.
 func FormatterNames() []string {
-	return DefaultFormatters.Names()
+	return DefaultRegistry.Names()
+}
+
+// FormatterUsage returns a human readable list of all globally registered
+// formats and their descriptions, e.g.
+// `json (JSON document), table (aligned columns)`. This is useful for command
+// line help text.
+func FormatterUsage() string {
+	return DefaultRegistry.Usage()
 }
@@ -0,0 +1,379 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/mgutz/ansi"
+)
+
+// DefaultTemplateFuncs returns a new template.FuncMap containing the built-in
+// template funcs which are made available to template-based formatters if
+// TemplateConfig.DefaultFuncs is true.
+//
+// Funcs that operate on a value take it as their last argument so that they
+// can be used in pipelines, e.g. `{{.name | trimPrefix "foo-" | upper}}`.
+//
+// Strings:
+//
+//   upper, lower, title, trim, trimPrefix, trimSuffix, replace, contains,
+//   hasPrefix, hasSuffix, split, join, repeat, trunc, quote, squote,
+//   indent, nindent
+//
+// Encoding:
+//
+//   toJson, toPrettyJson, toYaml
+//
+// Defaults:
+//
+//   default, empty, coalesce
+//
+// Time and units:
+//
+//   now, date, unixTime, humanBytes, humanDuration
+//
+// Colors (disabled if the NO_COLOR environment variable is set):
+//
+//   color, colorize
+func DefaultTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"title":      strings.Title,
+		"trim":       strings.TrimSpace,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+		"join":       join,
+		"repeat":     func(count int, s string) string { return strings.Repeat(s, count) },
+		"trunc":      trunc,
+		"quote":      func(v interface{}) string { return strconv.Quote(toString(v)) },
+		"squote":     func(v interface{}) string { return "'" + toString(v) + "'" },
+		"indent":     indent,
+		"nindent":    func(spaces int, s string) string { return "\n" + indent(spaces, s) },
+
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+		"toYaml":       toYAML,
+
+		"default":  defaultValue,
+		"empty":    isEmpty,
+		"coalesce": coalesce,
+
+		"now":           time.Now,
+		"date":          date,
+		"unixTime":      unixTime,
+		"humanBytes":    humanBytes,
+		"humanDuration": humanDuration,
+
+		"color":    color,
+		"colorize": colorize,
+	}
+}
+
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// join joins the elements of list with sep. List can be a slice of any type,
+// elements are converted to strings.
+func join(sep string, list interface{}) (string, error) {
+	if s, ok := list.([]string); ok {
+		return strings.Join(s, sep), nil
+	}
+
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: expected slice, got %T", list)
+	}
+
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = toString(rv.Index(i).Interface())
+	}
+
+	return strings.Join(parts, sep), nil
+}
+
+// trunc truncates s to at most length runes.
+func trunc(length int, s string) string {
+	runes := []rune(s)
+	if length < 0 || len(runes) <= length {
+		return s
+	}
+
+	return string(runes[:length])
+}
+
+// indent indents every line of s by the given number of spaces.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func toJSON(v interface{}) (string, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func toPrettyJSON(v interface{}) (string, error) {
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func toYAML(v interface{}) (string, error) {
+	buf, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(string(buf), "\n"), nil
+}
+
+// defaultValue returns def if v is empty, v otherwise.
+func defaultValue(def, v interface{}) interface{} {
+	if isEmpty(v) {
+		return def
+	}
+
+	return v
+}
+
+// coalesce returns the first non-empty value.
+func coalesce(values ...interface{}) interface{} {
+	for _, v := range values {
+		if !isEmpty(v) {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// isEmpty returns true if v is nil or the zero value of its type. Maps,
+// slices and strings are empty if their length is zero.
+func isEmpty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// date formats t using layout. T can be a time.Time, a *time.Time, an RFC3339
+// string or a unix timestamp in seconds.
+func date(layout string, t interface{}) (string, error) {
+	tm, err := toTime(t)
+	if err != nil {
+		return "", err
+	}
+
+	return tm.Format(layout), nil
+}
+
+// unixTime converts a unix timestamp in seconds into a time.Time.
+func unixTime(v interface{}) (time.Time, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	sec := int64(f)
+	nsec := int64((f - float64(sec)) * float64(time.Second))
+
+	return time.Unix(sec, nsec), nil
+}
+
+func toTime(v interface{}) (time.Time, error) {
+	switch v := v.(type) {
+	case time.Time:
+		return v, nil
+	case *time.Time:
+		return *v, nil
+	case string:
+		return time.Parse(time.RFC3339Nano, v)
+	default:
+		return unixTime(v)
+	}
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	default:
+		return 0, fmt.Errorf("cannot convert %T to number", v)
+	}
+}
+
+var byteUnits = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+
+// humanBytes formats a number of bytes using binary prefixes, e.g. 1536
+// yields "1.5 KiB".
+func humanBytes(v interface{}) (string, error) {
+	f, err := toFloat64(v)
+	if err != nil {
+		return "", err
+	}
+
+	i := 0
+	for ; f >= 1024 && i < len(byteUnits)-1; i++ {
+		f /= 1024
+	}
+
+	if i == 0 {
+		return fmt.Sprintf("%d %s", int64(f), byteUnits[i]), nil
+	}
+
+	// Round down to at most one decimal.
+	f = math.Floor(f*10) / 10
+
+	return fmt.Sprintf("%s %s", strconv.FormatFloat(f, 'f', -1, 64), byteUnits[i]), nil
+}
+
+// humanDuration formats a duration in a compact human readable form using the
+// two most significant units, e.g. "3d4h" or "5m30s". V can be a
+// time.Duration, a duration string like "90s" or a number of seconds.
+func humanDuration(v interface{}) (string, error) {
+	var d time.Duration
+
+	switch v := v.(type) {
+	case time.Duration:
+		d = v
+	case string:
+		pd, err := time.ParseDuration(v)
+		if err != nil {
+			return "", err
+		}
+
+		d = pd
+	default:
+		f, err := toFloat64(v)
+		if err != nil {
+			return "", err
+		}
+
+		d = time.Duration(f * float64(time.Second))
+	}
+
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+
+	if d < time.Second {
+		return sign + d.String(), nil
+	}
+
+	units := []struct {
+		suffix string
+		d      time.Duration
+	}{
+		{"d", 24 * time.Hour},
+		{"h", time.Hour},
+		{"m", time.Minute},
+		{"s", time.Second},
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(sign)
+
+	n := 0
+	for _, unit := range units {
+		if d < unit.d && n == 0 {
+			continue
+		}
+
+		if n == 2 {
+			break
+		}
+
+		n++
+
+		count := d / unit.d
+		d -= count * unit.d
+
+		if count > 0 {
+			fmt.Fprintf(&sb, "%d%s", count, unit.suffix)
+		}
+	}
+
+	return sb.String(), nil
+}
+
+// noColor returns true if the NO_COLOR environment variable is present.
+// See https://no-color.org/.
+func noColor() bool {
+	_, ok := os.LookupEnv("NO_COLOR")
+	return ok
+}
+
+// color returns the ANSI escape sequence for style, e.g. "red+b" or "reset".
+// Returns an empty string if colors are disabled.
+func color(style string) string {
+	if noColor() {
+		return ""
+	}
+
+	return ansi.ColorCode(style)
+}
+
+// colorize wraps s in the ANSI escape sequences for style. Returns s
+// unchanged if colors are disabled.
+func colorize(style string, s interface{}) string {
+	if noColor() {
+		return toString(s)
+	}
+
+	return ansi.Color(toString(s), style)
+}
@@ -0,0 +1,118 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultTemplateFuncs(t *testing.T) {
+	funcsConfig := func(tpl string) Config {
+		return Config{
+			Format:         "gotemplate",
+			Template:       tpl,
+			TemplateConfig: TemplateConfig{DefaultFuncs: true},
+		}
+	}
+
+	tests := []formatTestCase{
+		{
+			name: "default funcs are opt-in",
+			cfg:  Config{Format: "gotemplate", Template: "{{upper .}}"},
+			err:  errors.New("template: line 1: function \"upper\" not defined\n  1 | {{upper .}}"),
+		},
+		{
+			name: "string funcs",
+			cfg:  funcsConfig(`{{.name | trimPrefix "foo-" | upper}} {{replace "a" "o" "bar"}} {{trunc 3 "abcdef"}} {{quote .name}}`),
+			v:    map[string]interface{}{"name": "foo-bar"},
+			want: `BAR bor abc "foo-bar"`,
+		},
+		{
+			name: "split and join",
+			cfg:  funcsConfig(`{{split "," .list | join "; "}} {{join "-" .ints}}`),
+			v:    map[string]interface{}{"list": "a,b,c", "ints": []int{1, 2}},
+			want: "a; b; c 1-2",
+		},
+		{
+			name: "encoding funcs",
+			cfg:  funcsConfig(`{{toJson .}}{{toYaml . | nindent 2}}`),
+			v:    map[string]interface{}{"foo": []string{"bar"}},
+			want: "{\"foo\":[\"bar\"]}\n  foo:\n  - bar",
+		},
+		{
+			name: "default",
+			cfg:  funcsConfig(`{{.missing | default "none"}} {{.present | default "none"}} {{coalesce .missing "" "first"}}`),
+			v:    map[string]interface{}{"present": "here"},
+			want: "none here first",
+		},
+		{
+			name: "time funcs",
+			cfg:  funcsConfig(`{{date "2006-01-02" .created}} {{unixTime .ts | date "15:04"}}`),
+			v: map[string]interface{}{
+				"created": "2021-03-04T05:06:07Z",
+				"ts":      time.Date(2021, 1, 1, 13, 37, 0, 0, time.Local).Unix(),
+			},
+			want: "2021-03-04 13:37",
+		},
+		{
+			name: "human units",
+			cfg:  funcsConfig(`{{humanBytes 512}} {{humanBytes 1536}} {{humanBytes .size}} {{humanDuration 90}} {{humanDuration "26h3m4s"}} {{humanDuration .d}}`),
+			v:    map[string]interface{}{"size": 5 * 1024 * 1024 * 1024, "d": 300 * time.Millisecond},
+			want: "512 B 1.5 KiB 5 GiB 1m30s 1d2h 300ms",
+		},
+		{
+			name: "custom funcs override defaults",
+			cfg: Config{
+				Format:   "gotemplate",
+				Template: "{{upper .}}",
+				TemplateConfig: TemplateConfig{
+					DefaultFuncs: true,
+					Funcs:        template.FuncMap{"upper": func(s string) string { return "custom" }},
+				},
+			},
+			v:    "foo",
+			want: "custom",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
+
+func TestDefaultTemplateFuncs_colors(t *testing.T) {
+	defer restoreEnv("NO_COLOR")()
+	os.Unsetenv("NO_COLOR")
+
+	config := &Config{
+		Format:         "gotemplate",
+		Template:       `{{color "red"}}{{.}}{{color "reset"}} {{colorize "green" .}}`,
+		TemplateConfig: TemplateConfig{DefaultFuncs: true},
+	}
+
+	got, err := FormatString("foo", config)
+	require.NoError(t, err)
+	require.Equal(t, "\x1b[0;31mfoo\x1b[0m \x1b[0;32mfoo\x1b[0m", got)
+
+	os.Setenv("NO_COLOR", "")
+
+	got, err = FormatString("foo", config)
+	require.NoError(t, err)
+	require.Equal(t, "foo foo", got)
+}
+
+// restoreEnv returns a func that restores the environment variable with name
+// to its current state.
+func restoreEnv(name string) func() {
+	value, ok := os.LookupEnv(name)
+
+	return func() {
+		if ok {
+			os.Setenv(name, value)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
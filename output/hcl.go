@@ -0,0 +1,141 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/printer"
+)
+
+// hclIdentRegexp matches keys that can be written as bare identifiers.
+var hclIdentRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// formatHCL renders v as an HCL (version 1) document. V must be an object.
+// Nested objects are rendered as blocks, arrays as lists. Null values are
+// omitted from objects as HCL has no representation for them.
+func formatHCL(v interface{}, config *Config) ([]byte, error) {
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := nv.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("hcl: top-level value must be an object, got %T", v)
+	}
+
+	var buf bytes.Buffer
+
+	if err := writeHCLBody(&buf, m); err != nil {
+		return nil, err
+	}
+
+	// The printer takes care of indentation and alignment.
+	return printer.Format(buf.Bytes())
+}
+
+func writeHCLBody(buf *bytes.Buffer, m map[string]interface{}) error {
+	for _, key := range sortedKeys(m) {
+		value := m[key]
+
+		switch value.(type) {
+		case nil:
+			continue
+		case map[string]interface{}:
+			fmt.Fprintf(buf, "%s ", hclKey(key))
+		default:
+			fmt.Fprintf(buf, "%s = ", hclKey(key))
+		}
+
+		if err := writeHCLValue(buf, value); err != nil {
+			return err
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	return nil
+}
+
+func writeHCLValue(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		buf.WriteString("{\n")
+
+		if err := writeHCLBody(buf, v); err != nil {
+			return err
+		}
+
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+
+			if item == nil {
+				return errors.New("hcl: arrays must not contain null values")
+			}
+
+			if err := writeHCLValue(buf, item); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte(']')
+	case string:
+		buf.WriteString(hclQuote(v))
+	default:
+		buf.WriteString(stringify(v))
+	}
+
+	return nil
+}
+
+func hclKey(key string) string {
+	if hclIdentRegexp.MatchString(key) {
+		return key
+	}
+
+	return hclQuote(key)
+}
+
+// hclQuote returns s as quoted HCL string. Only the escape sequences defined
+// by HCL are used: \n, \r, \t, \", \\ and \uNNNN for other control
+// characters.
+func hclQuote(s string) string {
+	var sb strings.Builder
+
+	sb.Grow(len(s) + 2)
+	sb.WriteByte('"')
+
+	for _, r := range s {
+		switch r {
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+
+	sb.WriteByte('"')
+
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/hcl"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatHCL(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "non-object",
+			cfg:  Config{Format: "hcl"},
+			v:    "foo",
+			err:  errors.New("hcl: top-level value must be an object, got string"),
+		},
+		{
+			name: "null in array",
+			cfg:  Config{Format: "hcl"},
+			v:    map[string]interface{}{"list": []interface{}{1, nil}},
+			err:  errors.New("hcl: arrays must not contain null values"),
+		},
+		{
+			name: "nested values",
+			cfg:  Config{Format: "hcl"},
+			v: map[string]interface{}{
+				"name":    "foo \"bar\"",
+				"null":    nil,
+				"list":    []interface{}{1, "two", true},
+				"foo bar": map[string]interface{}{"enabled": false, "ratio": 0.5},
+			},
+			want: `"foo bar" {
+  enabled = false
+  ratio   = 0.5
+}
+
+list = [1, "two", true]
+
+name = "foo \"bar\""
+`,
+		},
+		{
+			name: "array of objects",
+			cfg:  Config{Format: "hcl"},
+			v:    map[string]interface{}{"items": []map[string]int{{"a": 1}}},
+			want: "items = [\n  {\n    a = 1\n  },\n]\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
+
+func TestFormatHCL_roundTrip(t *testing.T) {
+	v := map[string]interface{}{
+		"template": "${var.foo} %{if true} $${x} 100%",
+		"control":  "a\tb\x01\u007f\n\r\"c\"\\",
+		"${key}":   "unicode äöü",
+	}
+
+	out, err := FormatBytes(v, &Config{Format: "hcl"})
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, hcl.Unmarshal(out, &decoded))
+	require.Equal(t, v, decoded)
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"bytes"
+	"html/template"
+)
+
+var htmlOptions = []Option{
+	{Name: "title", Type: StringOption, Default: "", Usage: "wrap the output in a complete HTML document with the given title"},
+}
+
+var htmlTemplate = template.Must(template.New("html").Parse(`
+{{- define "node" -}}
+{{- if .Table -}}
+{{ template "table" .Table }}
+{{- else if .Entries -}}
+<dl>
+{{ range .Entries -}}
+<dt>{{ .Key }}</dt>
+<dd>{{ template "node" .Value }}</dd>
+{{ end -}}
+</dl>
+{{- else if .Items -}}
+<ul>
+{{ range .Items -}}
+<li>{{ template "node" . }}</li>
+{{ end -}}
+</ul>
+{{- else if and .Class (ne .Class "null") -}}
+<span class="{{ .Class }}">{{ .Text }}</span>
+{{- end -}}
+{{- end -}}
+
+{{- define "table" -}}
+<table>
+<thead>
+<tr>{{ range .Headers }}<th>{{ . }}</th>{{ end }}</tr>
+</thead>
+<tbody>
+{{ range .Rows -}}
+<tr>{{ range . }}<td>{{ template "node" . }}</td>{{ end }}</tr>
+{{ end -}}
+</tbody>
+</table>
+{{- end -}}
+
+{{- if .Title -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+</head>
+<body>
+<h1>{{ .Title }}</h1>
+{{ template "node" .Node }}
+</body>
+</html>
+{{ else -}}
+{{ template "node" .Node }}
+{{ end -}}
+`))
+
+// formatHTML renders v as HTML. Slices of objects become tables, objects
+// become definition lists and other slices become unordered lists. Nested
+// values are rendered recursively. Scalars are wrapped in spans with their
+// type as class to allow styling, null values are omitted. All values are
+// escaped by html/template.
+func formatHTML(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(htmlOptions, config)
+
+	node, err := newMarkupNode(v, config)
+	if err != nil {
+		return nil, err
+	}
+
+	data := struct {
+		Title string
+		Node  markupNode
+	}{
+		Title: opts.String("title"),
+		Node:  node,
+	}
+
+	var buf bytes.Buffer
+
+	if err := htmlTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,306 @@
+package output
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ValueFunc returns the value that should be formatted in response to r.
+type ValueFunc func(r *http.Request) (interface{}, error)
+
+// Handler is an http.Handler that formats the value returned by a ValueFunc
+// using the formatter negotiated with the client.
+//
+// The format is chosen as follows:
+//
+//   - The `format` query parameter selects a format by name or alias, e.g.
+//     `?format=yaml`.
+//   - Otherwise the media ranges of the Accept header are matched against the
+//     MIME types of the registered formatters in order of their quality.
+//   - If the Accept header is absent or accepts any media type, the format
+//     configured in the handler's Config is used.
+//
+// The `jsonpointer` query parameter overrides the JSONPointer of the
+// handler's Config. If no format matches, the handler responds with
+// 406 Not Acceptable and a list of the supported formats.
+//
+// Formats which need a template are only available if the handler's Config
+// has a Template or TemplateFiles, as clients cannot provide templates.
+type Handler struct {
+	value    ValueFunc
+	config   Config
+	compiled sync.Map
+}
+
+// NewHandler creates a new *Handler which formats the values returned by fn
+// according to config. The Format field of config configures the default
+// format. Config may be nil, in which case json is used by default.
+func NewHandler(fn ValueFunc, config *Config) *Handler {
+	h := &Handler{value: fn}
+
+	if config != nil {
+		h.config = *config
+	}
+
+	if h.config.Format == "" {
+		h.config.Format = "json"
+	}
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+
+	registry := configRegistry(&h.config)
+
+	name, ok := h.negotiateFormat(registry, r)
+	if !ok {
+		h.notAcceptable(w, registry)
+		return
+	}
+
+	pointer := r.URL.Query().Get("jsonpointer")
+
+	status := http.StatusInternalServerError
+	if pointer != "" {
+		// Most likely the client requested a pointer that is invalid or
+		// does not exist.
+		status = http.StatusBadRequest
+	}
+
+	c, err := h.compile(registry, name, pointer)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	v, err := h.value(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	buf, err := c.FormatBytes(v)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	name, _, _ = splitFormat(name)
+	meta, _ := registry.Metadata(name)
+
+	w.Header().Set("Content-Type", contentType(meta.MIMEType))
+	w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf)
+}
+
+// compile compiles the config for format name. Compiled configs without a
+// JSON pointer override are cached to avoid parsing templates and queries on
+// every request.
+func (h *Handler) compile(registry *Registry, name, pointer string) (*Compiled, error) {
+	if pointer == "" {
+		if c, ok := h.compiled.Load(name); ok {
+			return c.(*Compiled), nil
+		}
+	}
+
+	config := h.config
+	config.Format = name
+	config.Registry = registry
+	config.Formatters = nil
+
+	if pointer != "" {
+		config.JSONPointer = pointer
+	}
+
+	c, err := Compile(&config)
+	if err != nil {
+		return nil, err
+	}
+
+	if pointer == "" {
+		h.compiled.Store(name, c)
+	}
+
+	return c, nil
+}
+
+// negotiateFormat returns the name of the format that should be used to
+// respond to r. Returns false if no available format matches.
+func (h *Handler) negotiateFormat(registry *Registry, r *http.Request) (string, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		// Inline templates are not accepted from clients.
+		if strings.Contains(format, "=") {
+			return "", false
+		}
+
+		name, _, ok := registry.lookup(format)
+		if !ok || !h.available(registry, name) {
+			return "", false
+		}
+
+		return name, true
+	}
+
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return h.config.Format, true
+	}
+
+	for _, mediaRange := range parseAccept(strings.Join(accept, ",")) {
+		if name, ok := h.matchMediaRange(registry, mediaRange); ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// matchMediaRange returns the name of the first available format matching
+// mediaRange, which may contain wildcards, e.g. `text/*`.
+func (h *Handler) matchMediaRange(registry *Registry, mediaRange string) (string, bool) {
+	if mediaRange == "*/*" {
+		return h.config.Format, true
+	}
+
+	// The default format is preferred if it matches. It is checked
+	// explicitly because LookupMIMEType ignores formats that need a
+	// template.
+	name, _, _ := splitFormat(h.config.Format)
+
+	if meta, ok := registry.Metadata(name); ok && matchMIMEType(mediaRange, meta.MIMEType) {
+		return h.config.Format, true
+	}
+
+	if name, ok := registry.LookupMIMEType(mediaRange); ok {
+		return name, true
+	}
+
+	if !strings.HasSuffix(mediaRange, "/*") {
+		return "", false
+	}
+
+	for _, name := range registry.Names() {
+		meta, _ := registry.Metadata(name)
+
+		if h.available(registry, name) && matchMIMEType(mediaRange, meta.MIMEType) {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// available returns true if the format name can be used by h.
+func (h *Handler) available(registry *Registry, name string) bool {
+	meta, _ := registry.Metadata(name)
+
+	return !meta.NeedsTemplate || h.config.Template != "" || len(h.config.TemplateFiles) > 0
+}
+
+func (h *Handler) notAcceptable(w http.ResponseWriter, registry *Registry) {
+	var names []string
+
+	for _, name := range registry.Names() {
+		meta, _ := registry.Metadata(name)
+
+		if !h.available(registry, name) {
+			continue
+		}
+
+		if meta.MIMEType != "" {
+			name = fmt.Sprintf("%s (%s)", name, meta.MIMEType)
+		}
+
+		names = append(names, name)
+	}
+
+	http.Error(w, fmt.Sprintf("not acceptable, supported formats: %s", strings.Join(names, ", ")), http.StatusNotAcceptable)
+}
+
+// matchMIMEType returns true if mimeType matches mediaRange, which may be of
+// the form `type/*`.
+func matchMIMEType(mediaRange, mimeType string) bool {
+	if mimeType == "" {
+		return false
+	}
+
+	mimeType = baseMIMEType(mimeType)
+
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mimeType, strings.TrimSuffix(mediaRange, "*"))
+	}
+
+	return mediaRange == mimeType
+}
+
+// parseAccept parses the value of an Accept header and returns the media
+// ranges sorted by quality in descending order. Media ranges with the same
+// quality retain their order. Media ranges with a quality of zero are
+// dropped.
+func parseAccept(header string) []string {
+	type mediaRange struct {
+		value   string
+		quality float64
+	}
+
+	var ranges []mediaRange
+
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+
+		value := baseMIMEType(params[0])
+		if value == "" {
+			continue
+		}
+
+		quality := 1.0
+
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || strings.ToLower(kv[0]) != "q" {
+				continue
+			}
+
+			if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+				quality = q
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{value, quality})
+		}
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	values := make([]string, len(ranges))
+	for i, r := range ranges {
+		values[i] = r.value
+	}
+
+	return values
+}
+
+// contentType returns the value of the Content-Type header for responses of
+// the given MIME type. Defaults to text/plain.
+func contentType(mimeType string) string {
+	if mimeType == "" {
+		mimeType = "text/plain"
+	}
+
+	if strings.HasPrefix(mimeType, "text/") && !strings.Contains(mimeType, "charset") {
+		mimeType += "; charset=utf-8"
+	}
+
+	return mimeType
+}
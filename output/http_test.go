@@ -0,0 +1,170 @@
+package output
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	value := map[string]interface{}{"foo": map[string]interface{}{"bar": "baz"}}
+
+	tests := []struct {
+		name        string
+		config      *Config
+		target      string
+		accept      string
+		fn          ValueFunc
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "defaults to json",
+			target:      "/",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        "{\n  \"foo\": {\n    \"bar\": \"baz\"\n  }\n}",
+		},
+		{
+			name:        "configured default format",
+			config:      &Config{Format: "yaml"},
+			target:      "/",
+			accept:      "*/*",
+			status:      http.StatusOK,
+			contentType: "application/yaml",
+			body:        "foo:\n  bar: baz\n",
+		},
+		{
+			name:        "format query parameter",
+			target:      "/?format=yml",
+			accept:      "application/json",
+			status:      http.StatusOK,
+			contentType: "application/yaml",
+			body:        "foo:\n  bar: baz\n",
+		},
+		{
+			name:        "jsonpointer query parameter",
+			target:      "/?format=json&jsonpointer=/foo/bar",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `"baz"`,
+		},
+		{
+			name:        "invalid jsonpointer",
+			target:      "/?jsonpointer=/bar",
+			status:      http.StatusBadRequest,
+			contentType: "text/plain; charset=utf-8",
+			body:        "/bar at part 0: couldn't find key \"bar\"\n",
+		},
+		{
+			name:        "accept header with quality",
+			target:      "/",
+			accept:      "application/json;q=0.5, application/toml",
+			status:      http.StatusOK,
+			contentType: "application/toml",
+			body:        "[foo]\n  bar = \"baz\"\n",
+		},
+		{
+			name:        "accept header with wildcard",
+			target:      "/",
+			accept:      "text/*",
+			status:      http.StatusOK,
+			contentType: "text/csv; charset=utf-8",
+		},
+		{
+			name:        "prefers matching default format",
+			config:      &Config{Format: "gotemplate", Template: "{{.foo.bar}}"},
+			target:      "/",
+			accept:      "text/plain",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "baz",
+		},
+		{
+			name:        "default format with inline template",
+			config:      &Config{Format: "custom-columns=BAR:/foo/bar"},
+			target:      "/",
+			accept:      "text/plain",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "BAR\nbaz\n",
+		},
+		{
+			name:        "not acceptable",
+			target:      "/",
+			accept:      "image/png, application/json;q=0",
+			status:      http.StatusNotAcceptable,
+			contentType: "text/plain; charset=utf-8",
+			body: "not acceptable, supported formats: csv (text/csv), env, export, gostring, hcl, html (text/html), " +
+				"json (application/json), json-lines (application/x-ndjson), jsonschema (application/schema+json), " +
+				"markdown (text/markdown), " +
+				"properties (text/x-java-properties), table (text/plain), toml (application/toml), tree (text/plain), " +
+				"tsv (text/tab-separated-values), xml (application/xml), yaml (application/yaml)\n",
+		},
+		{
+			name:   "unknown format",
+			target: "/?format=png",
+			status: http.StatusNotAcceptable,
+		},
+		{
+			name:   "format requiring template",
+			target: "/?format=gotemplate",
+			status: http.StatusNotAcceptable,
+		},
+		{
+			name:   "inline template",
+			target: "/?format=gotemplate={{.foo}}",
+			status: http.StatusNotAcceptable,
+		},
+		{
+			name:   "value func error",
+			target: "/",
+			fn: func(r *http.Request) (interface{}, error) {
+				return nil, errors.New("whoops")
+			},
+			status:      http.StatusInternalServerError,
+			contentType: "text/plain; charset=utf-8",
+			body:        "whoops\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fn := test.fn
+			if fn == nil {
+				fn = func(r *http.Request) (interface{}, error) { return value, nil }
+			}
+
+			r := httptest.NewRequest(http.MethodGet, test.target, nil)
+			if test.accept != "" {
+				r.Header.Set("Accept", test.accept)
+			}
+
+			rec := httptest.NewRecorder()
+
+			NewHandler(fn, test.config).ServeHTTP(rec, r)
+
+			require.Equal(t, test.status, rec.Code)
+			require.Equal(t, "Accept", rec.Header().Get("Vary"))
+
+			if test.contentType != "" {
+				require.Equal(t, test.contentType, rec.Header().Get("Content-Type"))
+			}
+
+			if test.body != "" {
+				require.Equal(t, test.body, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestParseAccept(t *testing.T) {
+	require.Equal(t,
+		[]string{"text/html", "application/xml", "*/*"},
+		parseAccept("application/xml;q=0.9, text/html, image/png;q=0, */*;q=0.8"),
+	)
+}
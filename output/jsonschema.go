@@ -0,0 +1,430 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaDraft is the URI of the JSON Schema dialect of generated schemas.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema (draft 2020-12). It only contains the keywords
+// which are needed to describe the JSON representation of Go values.
+type Schema struct {
+	// Schema is the dialect of the schema. It is only set on the root
+	// schema.
+	Schema string `json:"$schema,omitempty"`
+	// Ref references a schema in the Defs of the root schema, e.g.
+	// `#/$defs/User`.
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	ContentEncoding      string             `json:"contentEncoding,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	// Defs contains the schemas of named struct types. It is only set on
+	// the root schema.
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+
+	// never makes the schema reject all values. It is encoded as false.
+	never bool
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.never {
+		return []byte("false"), nil
+	}
+
+	type schema Schema
+
+	return json.Marshal((*schema)(s))
+}
+
+// SchemaType is the value of the type keyword. It is encoded as string if it
+// contains a single type and as array otherwise.
+type SchemaType []string
+
+// MarshalJSON implements json.Marshaler.
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+
+	return json.Marshal([]string(t))
+}
+
+// has returns true if t contains typ.
+func (t SchemaType) has(typ string) bool {
+	for _, tt := range t {
+		if tt == typ {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	jsonNumberType = reflect.TypeOf(json.Number(""))
+)
+
+// SchemaFromType generates a JSON Schema which describes the JSON
+// representation of values of type t as produced by encoding/json. Field
+// names and the omitempty and string options of json struct tags are
+// honoured. Fields without omitempty are required. Named struct types are
+// placed into $defs and referenced, which also allows recursive types.
+// Values of pointers, slices and maps may be null. Types with custom JSON
+// marshalers accept any value unless they are known, like time.Time.
+func SchemaFromType(t reflect.Type) *Schema {
+	g := &schemaGenerator{defs: make(map[string]*Schema), names: make(map[reflect.Type]string)}
+
+	root := g.schema(t)
+	root.Schema = SchemaDraft
+
+	if len(g.defs) > 0 {
+		root.Defs = g.defs
+	}
+
+	return root
+}
+
+// schemaGenerator generates schemas from Go types.
+type schemaGenerator struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}
+	case jsonNumberType:
+		return &Schema{Type: SchemaType{"number"}}
+	}
+
+	pt := reflect.PtrTo(t)
+
+	switch {
+	case t.Kind() != reflect.Ptr && (t.Implements(jsonMarshalerType) || pt.Implements(jsonMarshalerType)):
+		return &Schema{}
+	case t.Kind() != reflect.Ptr && (t.Implements(textMarshalerType) || pt.Implements(textMarshalerType)):
+		return &Schema{Type: SchemaType{"string"}}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: SchemaType{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}}
+	case reflect.String:
+		return &Schema{Type: SchemaType{"string"}}
+	case reflect.Ptr:
+		return nullable(g.schema(t.Elem()))
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 && !reflect.PtrTo(t.Elem()).Implements(textMarshalerType) {
+			return nullable(&Schema{Type: SchemaType{"string"}, ContentEncoding: "base64"})
+		}
+
+		return nullable(&Schema{Type: SchemaType{"array"}, Items: g.schema(t.Elem())})
+	case reflect.Array:
+		return &Schema{Type: SchemaType{"array"}, Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return nullable(&Schema{Type: SchemaType{"object"}, AdditionalProperties: g.schema(t.Elem())})
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		return g.ref(t)
+	default:
+		// Interfaces accept any value. Channels, funcs and complex numbers
+		// cannot be encoded at all.
+		return &Schema{}
+	}
+}
+
+// ref returns a reference to the schema of the named struct type t in the
+// $defs, generating it first if necessary.
+func (g *schemaGenerator) ref(t reflect.Type) *Schema {
+	name, ok := g.names[t]
+	if !ok {
+		name = g.defName(t)
+		g.names[t] = name
+		// Register a placeholder before generating the schema to support
+		// recursive types.
+		g.defs[name] = &Schema{}
+		*g.defs[name] = *g.structSchema(t)
+	}
+
+	return &Schema{Ref: "#/$defs/" + name}
+}
+
+// defName returns a unique name for t within the $defs. Types with the same
+// name from different packages are qualified by their package path.
+func (g *schemaGenerator) defName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := g.defs[name]; !taken {
+		return name
+	}
+
+	return strings.NewReplacer("/", ".", "~", ".").Replace(t.PkgPath()) + "." + name
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 SchemaType{"object"},
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: &Schema{never: true},
+	}
+
+	g.addFields(s, t)
+
+	return s
+}
+
+// addFields adds the properties of the fields of struct type t to s. Fields
+// are resolved like encoding/json does. Fields are required unless they can
+// be omitted, which is the case for fields with the omitempty option which
+// are not structs and for fields promoted through embedded struct pointers.
+func (g *schemaGenerator) addFields(s *Schema, t reflect.Type) {
+	for _, field := range structFields(t) {
+		fs := g.schema(field.typ)
+		if field.stringJSON {
+			fs = stringEncoded(fs)
+		}
+
+		s.Properties[field.key] = fs
+
+		omittable := field.omitEmptyJSON && field.typ.Kind() != reflect.Struct
+		if !omittable && !field.embeddedPtr {
+			s.Required = append(s.Required, field.key)
+		}
+	}
+}
+
+// stringEncoded returns the schema of a field with the json string option.
+// The option only applies to scalar fields, which are encoded as strings.
+func stringEncoded(s *Schema) *Schema {
+	for _, typ := range []string{"boolean", "integer", "number"} {
+		if !s.Type.has(typ) {
+			continue
+		}
+
+		if s.Type.has("null") {
+			return &Schema{Type: SchemaType{"string", "null"}}
+		}
+
+		return &Schema{Type: SchemaType{"string"}}
+	}
+
+	return s
+}
+
+// nullable extends s to also accept null.
+func nullable(s *Schema) *Schema {
+	switch {
+	case s.Type == nil && s.Ref == "" && s.AnyOf == nil:
+		// Accepts any value already.
+		return s
+	case s.Type != nil && s.Ref == "" && s.AnyOf == nil:
+		if !s.Type.has("null") {
+			s.Type = append(s.Type, "null")
+		}
+
+		return s
+	default:
+		return &Schema{AnyOf: []*Schema{s, {Type: SchemaType{"null"}}}}
+	}
+}
+
+// SchemaFromValue infers a JSON Schema from the JSON representation of v.
+// Objects require all keys which are present, the items of arrays are
+// described by a single schema which accepts all items.
+func SchemaFromValue(v interface{}) (*Schema, error) {
+	v, err := maskSensitive(v)
+	if err != nil {
+		return nil, err
+	}
+
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	s := valueSchema(nv)
+	s.Schema = SchemaDraft
+
+	return s, nil
+}
+
+func valueSchema(nv interface{}) *Schema {
+	switch v := nv.(type) {
+	case nil:
+		return &Schema{Type: SchemaType{"null"}}
+	case bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &Schema{Type: SchemaType{"integer"}}
+		}
+
+		return &Schema{Type: SchemaType{"number"}}
+	case string:
+		return &Schema{Type: SchemaType{"string"}}
+	case map[string]interface{}:
+		s := &Schema{Type: SchemaType{"object"}, Properties: make(map[string]*Schema, len(v))}
+
+		for _, key := range sortedKeys(v) {
+			s.Properties[key] = valueSchema(v[key])
+			s.Required = append(s.Required, key)
+		}
+
+		return s
+	case []interface{}:
+		s := &Schema{Type: SchemaType{"array"}}
+
+		for _, item := range v {
+			s.Items = mergeSchemas(s.Items, valueSchema(item))
+		}
+
+		return s
+	default:
+		return &Schema{}
+	}
+}
+
+// mergeSchemas returns a schema which accepts all values accepted by a or b.
+// Both schemas must have been inferred by valueSchema, which only produces
+// schemas with a single type. Objects are merged by requiring only the
+// properties that are required by both, arrays by merging their items.
+// Different types are combined.
+func mergeSchemas(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+
+	if a.AnyOf != nil {
+		return mergeAnyOf(a.AnyOf, b)
+	}
+
+	if len(a.Type) != 1 || len(b.Type) != 1 {
+		return &Schema{AnyOf: []*Schema{a, b}}
+	}
+
+	ta, tb := a.Type[0], b.Type[0]
+
+	switch {
+	case ta == tb && ta == "object":
+		return mergeObjectSchemas(a, b)
+	case ta == tb && ta == "array":
+		return &Schema{Type: a.Type, Items: mergeArrayItems(a.Items, b.Items)}
+	case ta == tb:
+		return a
+	case ta == "integer" && tb == "number", ta == "number" && tb == "integer":
+		return &Schema{Type: SchemaType{"number"}}
+	default:
+		return &Schema{AnyOf: []*Schema{a, b}}
+	}
+}
+
+// mergeAnyOf merges s into the alternative of the same type or adds it as
+// new alternative.
+func mergeAnyOf(alternatives []*Schema, s *Schema) *Schema {
+	merged := make([]*Schema, len(alternatives))
+	copy(merged, alternatives)
+
+	for i, alt := range merged {
+		if m := mergeSchemas(alt, s); m.AnyOf == nil {
+			merged[i] = m
+			return &Schema{AnyOf: merged}
+		}
+	}
+
+	return &Schema{AnyOf: append(merged, s)}
+}
+
+func mergeArrayItems(a, b *Schema) *Schema {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	default:
+		return mergeSchemas(a, b)
+	}
+}
+
+func mergeObjectSchemas(a, b *Schema) *Schema {
+	s := &Schema{Type: a.Type, Properties: make(map[string]*Schema)}
+
+	for key, prop := range a.Properties {
+		s.Properties[key] = prop
+	}
+
+	for key, prop := range b.Properties {
+		s.Properties[key] = mergeSchemas(s.Properties[key], prop)
+	}
+
+	required := make(map[string]bool, len(b.Required))
+	for _, key := range b.Required {
+		required[key] = true
+	}
+
+	for _, key := range a.Required {
+		if required[key] {
+			s.Required = append(s.Required, key)
+		}
+	}
+
+	sort.Strings(s.Required)
+
+	return s
+}
+
+var jsonSchemaOptions = []Option{
+	{Name: "from", Type: StringOption, Default: "type", Usage: "infer the schema from the Go type or from the value, one of 'type' or 'value'"},
+}
+
+// formatJSONSchema renders a JSON Schema which describes the JSON
+// representation of v. By default the schema is generated from the Go type
+// of v, which yields little information for values which were selected via
+// Config.Query or other values of type interface{}. Use the from=value option
+// to infer the schema from the value instead.
+func formatJSONSchema(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(jsonSchemaOptions, config)
+
+	var (
+		s   *Schema
+		err error
+	)
+
+	switch from := opts.String("from"); from {
+	case "type":
+		s = &Schema{Schema: SchemaDraft}
+		if v != nil {
+			s = SchemaFromType(reflect.TypeOf(v))
+		}
+	case "value":
+		s, err = SchemaFromValue(v)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf(`jsonschema: invalid value %q for option "from", must be one of "type" or "value"`, from)
+	}
+
+	// The options of config belong to the jsonschema formatter.
+	return formatJSON(s, &Config{Color: config.Color})
+}
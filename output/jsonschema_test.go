@@ -0,0 +1,218 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type schemaTestNode struct {
+	Name     string            `json:"name"`
+	Children []*schemaTestNode `json:"children,omitempty"`
+}
+
+type schemaTestBase struct {
+	ID int64 `json:"id,string"`
+}
+
+type schemaTestUser struct {
+	schemaTestBase
+	Name      string            `json:"name"`
+	Email     *string           `json:"email,omitempty"`
+	Admin     bool              `json:"admin"`
+	Score     float64           `json:"-"`
+	Labels    map[string]string `json:"labels"`
+	Avatar    []byte            `json:"avatar,omitempty"`
+	CreatedAt time.Time         `json:"createdAt"`
+	Tree      schemaTestNode    `json:"tree"`
+	Extra     interface{}       `json:"extra,omitempty"`
+	Point     struct{ X, Y int }
+	internal  string
+}
+
+func TestSchemaFromType(t *testing.T) {
+	s := SchemaFromType(reflect.TypeOf([]schemaTestUser{}))
+
+	buf, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": ["array", "null"],
+		"items": {"$ref": "#/$defs/schemaTestUser"},
+		"$defs": {
+			"schemaTestUser": {
+				"type": "object",
+				"properties": {
+					"id": {"type": "string"},
+					"name": {"type": "string"},
+					"email": {"type": ["string", "null"]},
+					"admin": {"type": "boolean"},
+					"labels": {"type": ["object", "null"], "additionalProperties": {"type": "string"}},
+					"avatar": {"type": ["string", "null"], "contentEncoding": "base64"},
+					"createdAt": {"type": "string", "format": "date-time"},
+					"tree": {"$ref": "#/$defs/schemaTestNode"},
+					"extra": {},
+					"Point": {
+						"type": "object",
+						"properties": {"X": {"type": "integer"}, "Y": {"type": "integer"}},
+						"required": ["X", "Y"],
+						"additionalProperties": false
+					}
+				},
+				"required": ["id", "name", "admin", "labels", "createdAt", "tree", "Point"],
+				"additionalProperties": false
+			},
+			"schemaTestNode": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {
+						"type": ["array", "null"],
+						"items": {"anyOf": [{"$ref": "#/$defs/schemaTestNode"}, {"type": "null"}]}
+					}
+				},
+				"required": ["name"],
+				"additionalProperties": false
+			}
+		}
+	}`, string(buf))
+}
+
+type schemaTestEmbedded struct {
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+}
+
+type schemaTestOmit struct {
+	*schemaTestEmbedded
+	Name  string          `json:"name,omitempty"`
+	Point struct{ X int } `json:"point,omitempty"`
+}
+
+func TestSchemaFromType_fieldResolution(t *testing.T) {
+	s := SchemaFromType(reflect.TypeOf(schemaTestOmit{}))
+
+	buf, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$ref": "#/$defs/schemaTestOmit",
+		"$defs": {
+			"schemaTestOmit": {
+				"type": "object",
+				"properties": {
+					"owner": {"type": "string"},
+					"name": {"type": "string"},
+					"point": {
+						"type": "object",
+						"properties": {"X": {"type": "integer"}},
+						"required": ["X"],
+						"additionalProperties": false
+					}
+				},
+				"required": ["point"],
+				"additionalProperties": false
+			}
+		}
+	}`, string(buf))
+}
+
+func TestSchemaFromValue(t *testing.T) {
+	v := map[string]interface{}{
+		"items": []interface{}{
+			map[string]interface{}{"name": "foo", "count": 1, "tags": []interface{}{}},
+			map[string]interface{}{"name": "bar", "count": 1.5, "tags": []interface{}{"a"}, "owner": nil},
+		},
+		"mixed": []interface{}{"a", 1, nil, "b", 2.5},
+		"ok":    true,
+	}
+
+	s, err := SchemaFromValue(v)
+	require.NoError(t, err)
+
+	buf, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	require.JSONEq(t, `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"items": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"name": {"type": "string"},
+						"count": {"type": "number"},
+						"tags": {"type": "array", "items": {"type": "string"}},
+						"owner": {"type": "null"}
+					},
+					"required": ["count", "name", "tags"]
+				}
+			},
+			"mixed": {
+				"type": "array",
+				"items": {"anyOf": [{"type": "string"}, {"type": "number"}, {"type": "null"}]}
+			},
+			"ok": {"type": "boolean"}
+		},
+		"required": ["items", "mixed", "ok"]
+	}`, string(buf))
+}
+
+func TestFormatJSONSchema(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "from type",
+			cfg:  Config{Format: "jsonschema", FormatOptions: OptionValues{"from": "type"}},
+			v:    map[string]int{},
+			want: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": [
+    "object",
+    "null"
+  ],
+  "additionalProperties": {
+    "type": "integer"
+  }
+}`,
+		},
+		{
+			name: "from value after query",
+			cfg:  Config{Format: "jsonschema", Query: "$.items[0]", FormatOptions: OptionValues{"from": "value"}},
+			v:    map[string]interface{}{"items": []interface{}{map[string]interface{}{"a": "b"}}},
+			want: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "a": {
+      "type": "string"
+    }
+  },
+  "required": [
+    "a"
+  ]
+}`,
+		},
+		{
+			name: "nil",
+			cfg:  Config{Format: "jsonschema"},
+			want: `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema"
+}`,
+		},
+		{
+			name: "invalid source",
+			cfg:  Config{Format: "jsonschema", FormatOptions: OptionValues{"from": "foo"}},
+			err:  errors.New(`jsonschema: invalid value "foo" for option "from", must be one of "type" or "value"`),
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
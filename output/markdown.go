@@ -0,0 +1,175 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+)
+
+var markdownOptions = []Option{
+	{Name: "heading-level", Type: IntOption, Default: 2, Usage: "level of the headings of top-level object keys with nested objects or tables", Min: intBound(1), Max: intBound(6)},
+}
+
+// formatMarkdown renders v as GitHub flavored markdown. Slices of objects
+// become tables, objects become lists of bold keys and values. Keys of
+// objects which contain nested objects or tables become headings. Values
+// which cannot be represented in markdown, like objects within table cells,
+// are rendered as inline JSON code.
+func formatMarkdown(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(markdownOptions, config)
+
+	node, err := newMarkupNode(v, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	writeMarkdownBlock(&buf, node, opts.Int("heading-level"))
+
+	return buf.Bytes(), nil
+}
+
+// writeMarkdownBlock writes n as block level markdown. Blocks are separated
+// by blank lines.
+func writeMarkdownBlock(buf *bytes.Buffer, n markupNode, level int) {
+	switch {
+	case n.Table != nil:
+		writeMarkdownTable(buf, n.Table)
+	case len(n.Entries) > 0:
+		writeMarkdownEntries(buf, n.Entries, level)
+	case len(n.Items) > 0:
+		writeMarkdownList(buf, n.Items, "")
+	case n.Text != "":
+		buf.WriteString(markdownText(n.Text))
+		buf.WriteByte('\n')
+	}
+}
+
+// writeMarkdownEntries writes object entries as list. Consecutive entries
+// which are not blocks are grouped into one list, block entries are written
+// below a heading.
+func writeMarkdownEntries(buf *bytes.Buffer, entries []markupEntry, level int) {
+	inList := false
+
+	for i, entry := range entries {
+		if !entry.Value.isBlock() {
+			if !inList && i > 0 {
+				buf.WriteByte('\n')
+			}
+
+			inList = true
+			writeMarkdownListItem(buf, "**"+markdownText(entry.Key)+":**", entry.Value, "")
+			continue
+		}
+
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+
+		inList = false
+
+		if level > 6 {
+			buf.WriteString("**" + markdownText(entry.Key) + "**\n\n")
+		} else {
+			buf.WriteString(strings.Repeat("#", level) + " " + markdownText(entry.Key) + "\n\n")
+		}
+
+		writeMarkdownBlock(buf, entry.Value, level+1)
+	}
+}
+
+func writeMarkdownList(buf *bytes.Buffer, items []markupNode, indent string) {
+	for _, item := range items {
+		writeMarkdownListItem(buf, "", item, indent)
+	}
+}
+
+// writeMarkdownListItem writes a list item with an optional label. Non-empty
+// lists are nested below the item, blocks are inlined as JSON code.
+func writeMarkdownListItem(buf *bytes.Buffer, label string, n markupNode, indent string) {
+	buf.WriteString(indent + "-")
+
+	if label != "" {
+		buf.WriteString(" " + label)
+	}
+
+	if len(n.Items) > 0 {
+		buf.WriteByte('\n')
+		writeMarkdownList(buf, n.Items, indent+"  ")
+		return
+	}
+
+	if s := markdownInline(n); s != "" {
+		buf.WriteString(" " + s)
+	}
+
+	buf.WriteByte('\n')
+}
+
+func writeMarkdownTable(buf *bytes.Buffer, t *markupTable) {
+	cells := make([]string, len(t.Headers))
+
+	for i, header := range t.Headers {
+		cells[i] = markdownText(header)
+	}
+
+	writeMarkdownRow(buf, cells)
+
+	for i := range t.Headers {
+		cells[i] = "---"
+		if t.Numeric[i] {
+			cells[i] = "--:"
+		}
+	}
+
+	writeMarkdownRow(buf, cells)
+
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			cells[i] = markdownCell(markdownInline(cell))
+		}
+
+		writeMarkdownRow(buf, cells)
+	}
+}
+
+func writeMarkdownRow(buf *bytes.Buffer, cells []string) {
+	buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// markdownInline renders n as inline markdown. Scalars are rendered as text,
+// all other values as JSON code.
+func markdownInline(n markupNode) string {
+	if n.Table == nil && n.Entries == nil && n.Items == nil {
+		return markdownText(n.Text)
+	}
+
+	code := compactJSON(n.value)
+	if strings.Contains(code, "`") {
+		return "`` " + code + " ``"
+	}
+
+	return "`" + code + "`"
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", "&lt;", ">", "&gt;", "#", `\#`, "|", `\|`,
+	"\r\n", "<br>", "\n", "<br>",
+)
+
+// markdownText escapes characters of s which have a special meaning in
+// markdown. Newlines are converted to line breaks.
+func markdownText(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// markdownCell escapes pipes in inline code of table cells. Pipes in text are
+// already escaped by markdownText.
+func markdownCell(s string) string {
+	if !strings.HasPrefix(s, "`") {
+		return s
+	}
+
+	return strings.ReplaceAll(s, "|", `\|`)
+}
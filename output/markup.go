@@ -0,0 +1,165 @@
+package output
+
+import (
+	"encoding/json"
+)
+
+// markupNode is an intermediate representation of values that are rendered
+// by markup formatters like markdown and html. Exactly one of table, entries,
+// items or text is relevant, depending on the kind of the value.
+type markupNode struct {
+	// Table is set for non-empty slices of objects.
+	Table *markupTable
+	// Entries contains the entries of an object.
+	Entries []markupEntry
+	// Items contains the items of a slice which is not rendered as table.
+	Items []markupNode
+	// Text is the string representation of a scalar value.
+	Text string
+	// Class is the type of a scalar value, which is one of "string",
+	// "number", "bool" or "null".
+	Class string
+	// value is the normalized value the node was created from.
+	value interface{}
+}
+
+// markupTable is a table of objects.
+type markupTable struct {
+	Headers []string
+	Rows    [][]markupNode
+	// Numeric indicates for each column whether it only contains numbers.
+	Numeric []bool
+}
+
+// markupEntry is a key/value pair of an object.
+type markupEntry struct {
+	Key   string
+	Value markupNode
+}
+
+// newMarkupNode converts v into a markupNode. Slices of objects and single
+// objects at the top level are converted via newTabular, so that they respect
+// the configured columns and `output` struct tags. Nested values are
+// converted as is.
+func newMarkupNode(v interface{}, config *Config) (markupNode, error) {
+	t, err := newTabular(v, config.Columns, config.Wide)
+	if err != nil {
+		return markupNode{}, err
+	}
+
+	switch {
+	case t.object:
+		headers := t.headerNames(nil)
+		entries := make([]markupEntry, len(t.keys))
+
+		for i, header := range headers {
+			entries[i] = markupEntry{Key: header, Value: newNestedMarkupNode(t.rows[0][i])}
+		}
+
+		return markupNode{Entries: entries, value: v}, nil
+	case len(t.keys) > 0 && (len(t.keys) != 1 || t.keys[0] != valueKey || t.headers[0] != ""):
+		rows := make([][]markupNode, len(t.rows))
+
+		for i, row := range t.rows {
+			rows[i] = make([]markupNode, len(row))
+			for j, cell := range row {
+				rows[i][j] = newNestedMarkupNode(cell)
+			}
+		}
+
+		table := &markupTable{Headers: t.headerNames(nil), Rows: rows, Numeric: numericColumns(t)}
+
+		return markupNode{Table: table, value: v}, nil
+	}
+
+	nv, err := normalize(v)
+	if err != nil {
+		return markupNode{}, err
+	}
+
+	return newNestedMarkupNode(nv), nil
+}
+
+// newNestedMarkupNode converts the normalized value nv into a markupNode.
+// Slices which only contain objects become tables with the union of all
+// object keys as columns.
+func newNestedMarkupNode(nv interface{}) markupNode {
+	switch v := nv.(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		entries := make([]markupEntry, len(keys))
+
+		for i, key := range keys {
+			entries[i] = markupEntry{Key: key, Value: newNestedMarkupNode(v[key])}
+		}
+
+		return markupNode{Entries: entries, value: nv}
+	case []interface{}:
+		if table := newMarkupTable(v); table != nil {
+			return markupNode{Table: table, value: nv}
+		}
+
+		items := make([]markupNode, len(v))
+		for i, item := range v {
+			items[i] = newNestedMarkupNode(item)
+		}
+
+		return markupNode{Items: items, value: nv}
+	default:
+		return markupNode{Text: stringify(nv), Class: scalarClass(nv), value: nv}
+	}
+}
+
+// newMarkupTable creates a table from the normalized slice s. Returns nil if
+// s is empty or contains values other than objects.
+func newMarkupTable(s []interface{}) *markupTable {
+	if len(s) == 0 {
+		return nil
+	}
+
+	var keys keySet
+
+	objects := make([]map[string]interface{}, len(s))
+
+	for i, item := range s {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		objects[i] = m
+		keys.add(sortedKeys(m)...)
+	}
+
+	t := newObjectsTabular(keys.keys, nil, objects, nil, true)
+
+	rows := make([][]markupNode, len(t.rows))
+
+	for i, row := range t.rows {
+		rows[i] = make([]markupNode, len(row))
+		for j, cell := range row {
+			rows[i][j] = newNestedMarkupNode(cell)
+		}
+	}
+
+	return &markupTable{Headers: t.keys, Rows: rows, Numeric: numericColumns(t)}
+}
+
+func scalarClass(nv interface{}) string {
+	switch nv.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number:
+		return "number"
+	default:
+		return "string"
+	}
+}
+
+// isBlock returns true if n is rendered as a block element, i.e. as table or
+// definition list.
+func (n markupNode) isBlock() bool {
+	return n.Table != nil || len(n.Entries) > 0
+}
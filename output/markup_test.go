@@ -0,0 +1,155 @@
+package output
+
+import (
+	"errors"
+	"testing"
+)
+
+var markupTestValue = map[string]interface{}{
+	"name":  "status <page>",
+	"count": 2,
+	"tags":  []interface{}{"a|b", "c"},
+	"owner": map[string]interface{}{"team": "infra", "oncall": "*bob*"},
+	"checks": []interface{}{
+		map[string]interface{}{"name": "db", "ok": true, "latency": 12},
+		map[string]interface{}{"name": "api", "ok": false, "meta": map[string]interface{}{"a": "x|y"}},
+	},
+}
+
+func TestFormatMarkdown(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "nested object",
+			cfg:  Config{Format: "markdown"},
+			v:    markupTestValue,
+			want: "## checks\n\n" +
+				"| latency | name | ok | meta |\n" +
+				"| --: | --- | --- | --- |\n" +
+				"| 12 | db | true |  |\n" +
+				"|  | api | false | `{\"a\":\"x\\|y\"}` |\n" +
+				"\n" +
+				"- **count:** 2\n" +
+				"- **name:** status &lt;page&gt;\n" +
+				"\n" +
+				"## owner\n\n" +
+				"- **oncall:** \\*bob\\*\n" +
+				"- **team:** infra\n" +
+				"\n" +
+				"- **tags:**\n" +
+				"  - a\\|b\n" +
+				"  - c\n",
+		},
+		{
+			name: "heading level",
+			cfg:  Config{Format: "markdown", FormatOptions: OptionValues{"heading-level": 4}},
+			v:    map[string]interface{}{"a": map[string]interface{}{"b": map[string]interface{}{"c": 1}}},
+			want: "#### a\n\n##### b\n\n- **c:** 1\n",
+		},
+		{
+			name: "heading level out of range",
+			cfg:  Config{Format: "markdown", FormatOptions: OptionValues{"heading-level": 0}},
+			v:    map[string]interface{}{"a": 1},
+			err:  errors.New(`invalid value 0 for int option "heading-level": must be at least 1`),
+		},
+		{
+			name: "heading level too large",
+			cfg:  Config{Format: "markdown", FormatOptions: OptionValues{"heading-level": "7"}},
+			v:    map[string]interface{}{"a": 1},
+			err:  errors.New(`invalid value 7 for int option "heading-level": must be at most 6`),
+		},
+		{
+			name: "struct slice with tags and columns",
+			cfg:  Config{Format: "markdown", Columns: []string{"e-mail", "user"}},
+			v:    []fieldsTestUser{{Name: "foo", Email: "foo@example.com"}, {Name: "bar_baz", Email: "bar@example.com"}},
+			want: "| E-MAIL | USER |\n| --- | --- |\n| foo@example.com | foo |\n| bar@example.com | bar\\_baz |\n",
+		},
+		{
+			name: "slice of scalars",
+			cfg:  Config{Format: "markdown"},
+			v:    []interface{}{1, "two\nlines", []interface{}{}},
+			want: "- 1\n- two<br>lines\n- `[]`\n",
+		},
+		{
+			name: "scalar",
+			cfg:  Config{Format: "markdown"},
+			v:    "foo",
+			want: "foo\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
+
+func TestFormatHTML(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "nested object",
+			cfg:  Config{Format: "html"},
+			v:    markupTestValue,
+			want: `<dl>
+<dt>checks</dt>
+<dd><table>
+<thead>
+<tr><th>latency</th><th>name</th><th>ok</th><th>meta</th></tr>
+</thead>
+<tbody>
+<tr><td><span class="number">12</span></td><td><span class="string">db</span></td><td><span class="bool">true</span></td><td></td></tr>
+<tr><td></td><td><span class="string">api</span></td><td><span class="bool">false</span></td><td><dl>
+<dt>a</dt>
+<dd><span class="string">x|y</span></dd>
+</dl></td></tr>
+</tbody>
+</table></dd>
+<dt>count</dt>
+<dd><span class="number">2</span></dd>
+<dt>name</dt>
+<dd><span class="string">status &lt;page&gt;</span></dd>
+<dt>owner</dt>
+<dd><dl>
+<dt>oncall</dt>
+<dd><span class="string">*bob*</span></dd>
+<dt>team</dt>
+<dd><span class="string">infra</span></dd>
+</dl></dd>
+<dt>tags</dt>
+<dd><ul>
+<li><span class="string">a|b</span></li>
+<li><span class="string">c</span></li>
+</ul></dd>
+</dl>
+`,
+		},
+		{
+			name: "document with title",
+			cfg:  Config{Format: "html", FormatOptions: OptionValues{"title": "Users & Teams"}},
+			v:    []fieldsTestUser{{Name: "<script>", Password: "secret"}},
+			want: `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Users &amp; Teams</title>
+</head>
+<body>
+<h1>Users &amp; Teams</h1>
+<table>
+<thead>
+<tr><th>USER</th><th>token</th><th>pin</th></tr>
+</thead>
+<tbody>
+<tr><td><span class="string">&lt;script&gt;</span></td><td></td><td></td></tr>
+</tbody>
+</table>
+</body>
+</html>
+`,
+		},
+		{
+			name: "single struct",
+			cfg:  Config{Format: "html"},
+			v:    fieldsTestUser{Name: "foo"},
+			want: "<dl>\n<dt>USER</dt>\n<dd><span class=\"string\">foo</span></dd>\n<dt>token</dt>\n<dd></dd>\n<dt>pin</dt>\n<dd></dd>\n</dl>\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,56 @@
+package output
+
+import "strings"
+
+// Metadata describes a formatter.
+type Metadata struct {
+	// Description is a short human readable description of the output
+	// format, e.g. "JSON document".
+	Description string
+	// MIMEType is the media type of the formatted output, e.g.
+	// "application/json". May be empty if there is none.
+	MIMEType string
+	// Extensions contains the file extensions including the leading dot
+	// that are used for files in this format. The preferred extension comes
+	// first.
+	Extensions []string
+	// NeedsTemplate is true if the formatter requires a template, e.g. via
+	// Config.Template or inline as part of Config.Format.
+	NeedsTemplate bool
+}
+
+// DescribedFormatter is a Formatter which provides metadata about itself.
+type DescribedFormatter interface {
+	Formatter
+	// Metadata returns the formatter's metadata.
+	Metadata() Metadata
+}
+
+// FormatterMetadata returns the metadata of f. Returns the zero value if f
+// does not implement DescribedFormatter.
+func FormatterMetadata(f Formatter) Metadata {
+	if df, ok := f.(DescribedFormatter); ok {
+		return df.Metadata()
+	}
+
+	return Metadata{}
+}
+
+// normalizeExtension ensures that ext is lowercase and starts with a dot.
+func normalizeExtension(ext string) string {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	return ext
+}
+
+// baseMIMEType strips parameters like the charset from mimeType.
+func baseMIMEType(mimeType string) string {
+	if i := strings.IndexByte(mimeType, ';'); i != -1 {
+		mimeType = mimeType[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(mimeType))
+}
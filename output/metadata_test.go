@@ -0,0 +1,81 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatterMetadata(t *testing.T) {
+	json, _ := DefaultRegistry.Lookup("json")
+	require.Equal(t, Metadata{
+		Description: "JSON document",
+		MIMEType:    "application/json",
+		Extensions:  []string{".json"},
+	}, FormatterMetadata(json))
+
+	require.Equal(t, Metadata{}, FormatterMetadata(FormatFunc(formatJSON)))
+
+	meta, ok := DefaultRegistry.Metadata("yml")
+	require.True(t, ok)
+	require.Equal(t, "YAML document", meta.Description)
+
+	meta, ok = DefaultRegistry.Metadata("gotemplate")
+	require.True(t, ok)
+	require.True(t, meta.NeedsTemplate)
+
+	_, ok = DefaultRegistry.Metadata("nonexistent")
+	require.False(t, ok)
+}
+
+func TestRegistry_LookupExtension(t *testing.T) {
+	tests := []struct {
+		ext  string
+		want string
+	}{
+		{ext: ".json", want: "json"},
+		{ext: "YML", want: "yaml"},
+		{ext: ".ndjson", want: "json-lines"},
+		{ext: ".txt", want: "table"},
+		{ext: ".exe"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.ext, func(t *testing.T) {
+			name, ok := DefaultRegistry.LookupExtension(test.ext)
+			require.Equal(t, test.want != "", ok)
+			require.Equal(t, test.want, name)
+		})
+	}
+}
+
+func TestRegistry_LookupMIMEType(t *testing.T) {
+	tests := []struct {
+		mimeType string
+		want     string
+	}{
+		{mimeType: "application/json", want: "json"},
+		{mimeType: "Application/YAML; charset=utf-8", want: "yaml"},
+		{mimeType: "text/plain", want: "table"},
+		{mimeType: "image/png"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.mimeType, func(t *testing.T) {
+			name, ok := DefaultRegistry.LookupMIMEType(test.mimeType)
+			require.Equal(t, test.want != "", ok)
+			require.Equal(t, test.want, name)
+		})
+	}
+}
+
+func TestRegistry_Usage(t *testing.T) {
+	r := NewRegistry(nil)
+	r.RegisterFormatFunc("plain", formatJSON)
+	r.RegisterFormatter("json", &builtinFormatter{
+		Formatter: FormatFunc(formatJSON),
+		metadata:  Metadata{Description: "JSON document"},
+	})
+
+	require.Equal(t, "json (JSON document), plain", r.Usage())
+}
@@ -0,0 +1,225 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// OptionType is the type of the value of a formatter option.
+type OptionType int
+
+const (
+	// BoolOption is an option with a bool value.
+	BoolOption OptionType = iota
+	// IntOption is an option with an int value.
+	IntOption
+	// StringOption is an option with a string value.
+	StringOption
+)
+
+// String implements fmt.Stringer.
+func (t OptionType) String() string {
+	switch t {
+	case BoolOption:
+		return "bool"
+	case IntOption:
+		return "int"
+	case StringOption:
+		return "string"
+	default:
+		return fmt.Sprintf("OptionType(%d)", int(t))
+	}
+}
+
+// Option declares an option that is accepted by a formatter.
+type Option struct {
+	// Name is the name of the option, e.g. "indent".
+	Name string
+	// Type is the type of the option's value.
+	Type OptionType
+	// Default is the value that is used if the option is not provided. Its
+	// type must match Type.
+	Default interface{}
+	// Usage is a short description of the option.
+	Usage string
+	// Min optionally configures the minimum value of an IntOption. Smaller
+	// values are rejected.
+	Min *int
+	// Max optionally configures the maximum value of an IntOption. Larger
+	// values are rejected.
+	Max *int
+}
+
+// intBound returns a pointer to n for use as Option.Min or Option.Max.
+func intBound(n int) *int {
+	return &n
+}
+
+// Parse parses s into a value of the option's type.
+func (o Option) Parse(s string) (interface{}, error) {
+	switch o.Type {
+	case BoolOption:
+		return strconv.ParseBool(s)
+	case IntOption:
+		return strconv.Atoi(s)
+	default:
+		return s, nil
+	}
+}
+
+// check ensures that v is of the option's type and within its bounds.
+// Strings are parsed into the option's type.
+func (o Option) check(v interface{}) (interface{}, error) {
+	if s, ok := v.(string); ok && o.Type != StringOption {
+		pv, err := o.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %q for %s option %q", s, o.Type, o.Name)
+		}
+
+		return o.checkBounds(pv)
+	}
+
+	var ok bool
+
+	switch o.Type {
+	case BoolOption:
+		_, ok = v.(bool)
+	case IntOption:
+		_, ok = v.(int)
+	default:
+		_, ok = v.(string)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("invalid value %#v for %s option %q", v, o.Type, o.Name)
+	}
+
+	return o.checkBounds(v)
+}
+
+// checkBounds ensures that the value of an IntOption is within Min and Max.
+func (o Option) checkBounds(v interface{}) (interface{}, error) {
+	i, ok := v.(int)
+	if !ok {
+		return v, nil
+	}
+
+	if o.Min != nil && i < *o.Min {
+		return nil, fmt.Errorf("invalid value %d for int option %q: must be at least %d", i, o.Name, *o.Min)
+	}
+
+	if o.Max != nil && i > *o.Max {
+		return nil, fmt.Errorf("invalid value %d for int option %q: must be at most %d", i, o.Name, *o.Max)
+	}
+
+	return v, nil
+}
+
+// ConfigurableFormatter is a Formatter which accepts options via
+// Config.FormatOptions.
+type ConfigurableFormatter interface {
+	Formatter
+	// Options returns the declarations of all options the formatter
+	// accepts.
+	Options() []Option
+}
+
+// FormatterOptions returns the options accepted by f. Returns nil if f does
+// not implement ConfigurableFormatter. This can be used to surface formatter
+// options as command line flags.
+func FormatterOptions(f Formatter) []Option {
+	if cf, ok := f.(ConfigurableFormatter); ok {
+		return cf.Options()
+	}
+
+	return nil
+}
+
+// OptionValues holds formatter option values keyed by option name.
+type OptionValues map[string]interface{}
+
+// Bool returns the value of the bool option with name. Returns false if the
+// option is not present or not a bool.
+func (v OptionValues) Bool(name string) bool {
+	b, _ := v[name].(bool)
+	return b
+}
+
+// Int returns the value of the int option with name. Returns 0 if the option
+// is not present or not an int.
+func (v OptionValues) Int(name string) int {
+	i, _ := v[name].(int)
+	return i
+}
+
+// String returns the value of the string option with name. Returns an empty
+// string if the option is not present or not a string.
+func (v OptionValues) String(name string) string {
+	s, _ := v[name].(string)
+	return s
+}
+
+// resolveOptions validates values against the options declared by f and
+// fills in defaults for options that are not present. String values are
+// parsed into the declared option type. Returns an error if values contains
+// options which are not declared by f.
+func resolveOptions(f Formatter, format string, values OptionValues) (OptionValues, error) {
+	options := FormatterOptions(f)
+
+	declared := make(map[string]Option, len(options))
+	for _, opt := range options {
+		declared[opt.Name] = opt
+	}
+
+	for _, name := range sortedOptionNames(values) {
+		if _, ok := declared[name]; !ok {
+			return nil, fmt.Errorf("format %q does not support option %q", format, name)
+		}
+	}
+
+	resolved := make(OptionValues, len(options))
+
+	for _, opt := range options {
+		v, ok := values[opt.Name]
+		if !ok {
+			resolved[opt.Name] = opt.Default
+			continue
+		}
+
+		v, err := opt.check(v)
+		if err != nil {
+			return nil, err
+		}
+
+		resolved[opt.Name] = v
+	}
+
+	return resolved, nil
+}
+
+// optionValues returns the values of options from config, falling back to
+// the option defaults for values that are not present.
+func optionValues(options []Option, config *Config) OptionValues {
+	values := make(OptionValues, len(options))
+
+	for _, opt := range options {
+		if v, ok := config.FormatOptions[opt.Name]; ok {
+			values[opt.Name] = v
+		} else {
+			values[opt.Name] = opt.Default
+		}
+	}
+
+	return values
+}
+
+func sortedOptionNames(values OptionValues) []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,107 @@
+package output
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatOptions(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+		HTML string `json:"html"`
+	}
+
+	tests := []formatTestCase{
+		{
+			name: "unsupported option",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"foo": true}},
+			err:  errors.New(`format "json" does not support option "foo"`),
+		},
+		{
+			name: "format without options",
+			cfg:  Config{Format: "gostring", FormatOptions: OptionValues{"indent": 4}},
+			err:  errors.New(`format "gostring" does not support option "indent"`),
+		},
+		{
+			name: "invalid value type",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"indent": true}},
+			err:  errors.New(`invalid value true for int option "indent"`),
+		},
+		{
+			name: "invalid string value",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"compact": "maybe"}},
+			err:  errors.New(`invalid value "maybe" for bool option "compact"`),
+		},
+		{
+			name: "negative json indent",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"indent": -1}},
+			err:  errors.New(`invalid value -1 for int option "indent": must be at least 0`),
+		},
+		{
+			name: "negative json indent parsed from string",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"indent": "-1"}},
+			err:  errors.New(`invalid value -1 for int option "indent": must be at least 0`),
+		},
+		{
+			name: "json indent",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"indent": 4}},
+			v:    map[string]interface{}{"foo": "bar"},
+			want: "{\n    \"foo\": \"bar\"\n}",
+		},
+		{
+			name: "json options parsed from strings",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"compact": "true", "escape-html": "false"}},
+			v:    item{Name: "foo", HTML: "<b>"},
+			want: `{"name":"foo","html":"<b>"}`,
+		},
+		{
+			name: "json escapes html by default",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"compact": true}},
+			v:    item{Name: "foo", HTML: "<b>"},
+			want: `{"name":"foo","html":"\u003cb\u003e"}`,
+		},
+		{
+			name: "json sort keys",
+			cfg:  Config{Format: "json", FormatOptions: OptionValues{"compact": true, "sort-keys": true}},
+			v:    item{Name: "foo", HTML: "bar"},
+			want: `{"html":"bar","name":"foo"}`,
+		},
+		{
+			name: "yaml flow",
+			cfg:  Config{Format: "yaml", FormatOptions: OptionValues{"flow": true}},
+			v: map[string]interface{}{
+				"list":   []interface{}{1, "two", true, nil},
+				"nested": map[string]interface{}{"a b": "c, d", "num": "1"},
+				"empty":  map[string]interface{}{},
+			},
+			want: "{empty: {}, list: [1, two, true, null], nested: {\"a b\": \"c, d\", num: \"1\"}}\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
+
+func TestFormatterOptions(t *testing.T) {
+	gostring, _ := DefaultRegistry.Lookup("gostring")
+	require.Nil(t, FormatterOptions(gostring))
+
+	json, _ := DefaultRegistry.Lookup("json")
+	require.Equal(t, jsonOptions, FormatterOptions(json))
+
+	opt := Option{Name: "indent", Type: IntOption}
+
+	v, err := opt.Parse("4")
+	require.NoError(t, err)
+	require.Equal(t, 4, v)
+
+	opt.Min, opt.Max = intBound(1), intBound(3)
+
+	_, err = opt.check(4)
+	require.EqualError(t, err, `invalid value 4 for int option "indent": must be at most 3`)
+
+	v, err = opt.check("3")
+	require.NoError(t, err)
+	require.Equal(t, 3, v)
+}
@@ -0,0 +1,295 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Registry is a concurrency-safe registry of named formatters. Formats can be
+// registered under additional names via aliases, e.g. "yml" for "yaml".
+//
+// A registry can be layered on top of a parent registry. Lookups that cannot
+// be satisfied by the registry itself are delegated to the parent, which
+// makes it possible to add or override formatters for a single command
+// without affecting the parent. Formatters registered in the parent after the
+// child was created are visible to the child as well.
+//
+// A frozen registry rejects further registrations. This can be used to
+// ensure that a registry is fully set up before it is used.
+type Registry struct {
+	mu         sync.RWMutex
+	parent     *Registry
+	formatters map[string]Formatter
+	aliases    map[string]string
+	frozen     bool
+	// legacy is consulted before formatters. It is only set for the
+	// DefaultRegistry, which reads from DefaultFormatters.
+	legacy FormatterMap
+}
+
+// NewRegistry creates a new empty *Registry. Parent is optional and may be
+// nil.
+func NewRegistry(parent *Registry) *Registry {
+	return &Registry{
+		parent:     parent,
+		formatters: make(map[string]Formatter),
+		aliases:    make(map[string]string),
+	}
+}
+
+// RegisterFormatter registers a Formatter. Panics if the registry is frozen
+// or if a formatter or alias with the same name was already registered in r.
+// Formatters of the parent registry can be overridden.
+func (r *Registry) RegisterFormatter(name string, f Formatter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkRegister(name)
+
+	r.formatters[name] = f
+}
+
+// RegisterFormatFunc registers a FormatFunc. Panics under the same conditions
+// as RegisterFormatter.
+func (r *Registry) RegisterFormatFunc(name string, f FormatFunc) {
+	r.RegisterFormatter(name, f)
+}
+
+// RegisterAlias registers alias as an alternative name for the format name.
+// Name may itself be an alias and must be resolvable by r or one of its
+// parents. Panics under the same conditions as RegisterFormatter or if name
+// cannot be resolved.
+func (r *Registry) RegisterAlias(alias, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checkRegister(alias)
+
+	canonical, _, ok := r.lookupLocked(name)
+	if !ok {
+		panic(fmt.Sprintf("cannot register alias %q for unknown format %q", alias, name))
+	}
+
+	r.aliases[alias] = canonical
+}
+
+func (r *Registry) checkRegister(name string) {
+	if r.frozen {
+		panic(fmt.Sprintf("cannot register %q: registry is frozen", name))
+	}
+
+	if _, exists := r.localFormatter(name); exists {
+		panic(fmt.Sprintf("formatter with name %q already registered", name))
+	}
+
+	if _, exists := r.aliases[name]; exists {
+		panic(fmt.Sprintf("alias with name %q already registered", name))
+	}
+}
+
+// Freeze freezes r. Any subsequent registration panics. Parent registries
+// are not affected.
+func (r *Registry) Freeze() {
+	r.mu.Lock()
+	r.frozen = true
+	r.mu.Unlock()
+}
+
+// Frozen returns true if r is frozen.
+func (r *Registry) Frozen() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.frozen
+}
+
+// Lookup looks up the formatter for name, which may be an alias. Returns
+// false if neither r nor any of its parents have a formatter for name.
+func (r *Registry) Lookup(name string) (Formatter, bool) {
+	_, f, ok := r.lookup(name)
+	return f, ok
+}
+
+// lookup is like Lookup but additionally returns the canonical name of the
+// format if name is an alias.
+func (r *Registry) lookup(name string) (string, Formatter, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.lookupLocked(name)
+}
+
+func (r *Registry) lookupLocked(name string) (string, Formatter, bool) {
+	if f, ok := r.localFormatter(name); ok {
+		return name, f, true
+	}
+
+	if canonical, ok := r.aliases[name]; ok {
+		if f, ok := r.localFormatter(canonical); ok {
+			return canonical, f, true
+		}
+
+		name = canonical
+	}
+
+	if r.parent == nil {
+		return "", nil, false
+	}
+
+	return r.parent.lookup(name)
+}
+
+// localFormatter looks up the formatter for name in r without considering
+// aliases and parents. The caller must hold r.mu.
+func (r *Registry) localFormatter(name string) (Formatter, bool) {
+	if f, ok := r.legacy[name]; ok {
+		return f, true
+	}
+
+	f, ok := r.formatters[name]
+	return f, ok
+}
+
+// Names returns a sorted slice of the names of all formatters in r and its
+// parents. Aliases are not included. This is useful to present allowed values
+// in command line flags.
+func (r *Registry) Names() []string {
+	seen := make(map[string]bool)
+
+	for reg := r; reg != nil; reg = reg.parent {
+		reg.mu.RLock()
+		for name := range reg.formatters {
+			seen[name] = true
+		}
+
+		for name := range reg.legacy {
+			seen[name] = true
+		}
+		reg.mu.RUnlock()
+	}
+
+	return sortedNames(seen)
+}
+
+// Aliases returns a map of all aliases in r and its parents to the canonical
+// names of the formats they refer to.
+func (r *Registry) Aliases() map[string]string {
+	aliases := make(map[string]string)
+
+	for reg := r; reg != nil; reg = reg.parent {
+		reg.mu.RLock()
+		for alias, name := range reg.aliases {
+			if _, ok := aliases[alias]; !ok {
+				aliases[alias] = name
+			}
+		}
+		reg.mu.RUnlock()
+	}
+
+	return aliases
+}
+
+// Metadata returns the metadata of the formatter for name, which may be an
+// alias. Returns false if there is no formatter for name.
+func (r *Registry) Metadata(name string) (Metadata, bool) {
+	f, ok := r.Lookup(name)
+	if !ok {
+		return Metadata{}, false
+	}
+
+	return FormatterMetadata(f), true
+}
+
+// LookupExtension returns the name of the format that uses the file extension
+// ext, e.g. ".yml". The leading dot is optional and extensions are matched
+// case-insensitively. If multiple formats declare the extension, the first
+// one in lexical order wins.
+func (r *Registry) LookupExtension(ext string) (string, bool) {
+	ext = normalizeExtension(ext)
+
+	for _, name := range r.Names() {
+		meta, _ := r.Metadata(name)
+
+		for _, e := range meta.Extensions {
+			if normalizeExtension(e) == ext {
+				return name, true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// LookupMIMEType returns the name of the format that produces output with the
+// given MIME type. Parameters like charset are ignored. Formats that need a
+// template are not considered since they cannot produce output on their own.
+// If multiple formats declare the MIME type, the first one in lexical order
+// wins.
+func (r *Registry) LookupMIMEType(mimeType string) (string, bool) {
+	mimeType = baseMIMEType(mimeType)
+
+	for _, name := range r.Names() {
+		meta, _ := r.Metadata(name)
+
+		if !meta.NeedsTemplate && meta.MIMEType != "" && baseMIMEType(meta.MIMEType) == mimeType {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// Usage returns a human readable list of all formats and their descriptions
+// that is suitable for command line help text, e.g.
+// `json (JSON document), table (aligned columns)`.
+func (r *Registry) Usage() string {
+	var sb strings.Builder
+
+	for i, name := range r.Names() {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteString(name)
+
+		if meta, _ := r.Metadata(name); meta.Description != "" {
+			fmt.Fprintf(&sb, " (%s)", meta.Description)
+		}
+	}
+
+	return sb.String()
+}
+
+func sortedNames(set map[string]bool) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// DefaultRegistry contains the built-in formatters and can be extended to make
+// more formatters globally available. It is used as a fallback if the user
+// does not provide a custom registry as part of the config to Format* funcs.
+// Use NewRegistry(DefaultRegistry) to customize formatters for a single
+// command.
+var DefaultRegistry = newDefaultRegistry()
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry(nil)
+	r.legacy = DefaultFormatters
+
+	for name, f := range builtinFormatters {
+		if _, ok := DefaultFormatters[name]; !ok {
+			r.RegisterFormatter(name, f)
+		}
+	}
+
+	r.RegisterAlias("yml", "yaml")
+	r.RegisterAlias("jsonl", "json-lines")
+	r.RegisterAlias("ndjson", "json-lines")
+
+	return r
+}
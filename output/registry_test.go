@@ -0,0 +1,172 @@
+package output
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	foo := FormatFunc(func(v interface{}, config *Config) ([]byte, error) { return []byte("foo"), nil })
+	bar := FormatFunc(func(v interface{}, config *Config) ([]byte, error) { return []byte("bar"), nil })
+
+	parent := NewRegistry(nil)
+	parent.RegisterFormatter("foo", foo)
+	parent.RegisterAlias("f", "foo")
+	parent.RegisterAlias("ff", "f")
+
+	child := NewRegistry(parent)
+	child.RegisterFormatter("bar", bar)
+	child.RegisterAlias("b", "bar")
+	child.RegisterAlias("fb", "f")
+
+	// Parent formatters can be overridden by the child.
+	child.RegisterFormatFunc("foo", bar)
+
+	tests := []struct {
+		registry  *Registry
+		name      string
+		canonical string
+		want      string
+	}{
+		{registry: parent, name: "foo", canonical: "foo", want: "foo"},
+		{registry: parent, name: "ff", canonical: "foo", want: "foo"},
+		{registry: parent, name: "bar"},
+		{registry: child, name: "bar", canonical: "bar", want: "bar"},
+		{registry: child, name: "b", canonical: "bar", want: "bar"},
+		{registry: child, name: "foo", canonical: "foo", want: "bar"},
+		{registry: child, name: "f", canonical: "foo", want: "foo"},
+		{registry: child, name: "fb", canonical: "foo", want: "bar"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			canonical, f, ok := test.registry.lookup(test.name)
+			if test.want == "" {
+				require.False(t, ok)
+				return
+			}
+
+			require.True(t, ok)
+			require.Equal(t, test.canonical, canonical)
+
+			buf, err := f.Format(nil, nil)
+			require.NoError(t, err)
+			require.Equal(t, test.want, string(buf))
+		})
+	}
+
+	require.Equal(t, []string{"foo"}, parent.Names())
+	require.Equal(t, []string{"bar", "foo"}, child.Names())
+	require.Equal(t, map[string]string{"f": "foo", "ff": "foo", "b": "bar", "fb": "foo"}, child.Aliases())
+
+	require.PanicsWithValue(t, `formatter with name "bar" already registered`, func() {
+		child.RegisterFormatter("bar", foo)
+	})
+	require.PanicsWithValue(t, `alias with name "b" already registered`, func() {
+		child.RegisterFormatter("b", foo)
+	})
+	require.PanicsWithValue(t, `cannot register alias "x" for unknown format "y"`, func() {
+		child.RegisterAlias("x", "y")
+	})
+
+	child.Freeze()
+	require.True(t, child.Frozen())
+	require.False(t, parent.Frozen())
+	require.PanicsWithValue(t, `cannot register "baz": registry is frozen`, func() {
+		child.RegisterFormatter("baz", foo)
+	})
+
+	// Formatters registered in the parent later on are visible to the child.
+	parent.RegisterFormatter("baz", foo)
+	_, ok := child.Lookup("baz")
+	require.True(t, ok)
+}
+
+func TestDefaultFormatters(t *testing.T) {
+	for name, f := range DefaultFormatters {
+		rf, ok := DefaultRegistry.Lookup(name)
+		require.True(t, ok)
+		require.Equal(t, f, rf)
+	}
+
+	// Formatters added to the map are picked up by the DefaultRegistry.
+	DefaultFormatters["legacy"] = FormatFunc(func(v interface{}, config *Config) ([]byte, error) {
+		return []byte("legacy"), nil
+	})
+	t.Cleanup(func() { delete(DefaultFormatters, "legacy") })
+
+	out, err := FormatString(nil, &Config{Format: "legacy"})
+	require.NoError(t, err)
+	require.Equal(t, "legacy", out)
+	require.Contains(t, FormatterNames(), "legacy")
+	require.Panics(t, func() { RegisterFormatFunc("legacy", formatJSON) })
+
+	// Formatters added after DefaultFormatters was introduced do not clash
+	// with copies of it.
+	require.NotPanics(t, func() {
+		DefaultFormatters.DeepCopy().RegisterFormatFunc("xml", formatJSON)
+	})
+}
+
+func TestRegistry_concurrency(t *testing.T) {
+	r := NewRegistry(DefaultRegistry)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func(i int) {
+			defer wg.Done()
+			r.RegisterFormatFunc(fmt.Sprintf("custom-%d", i), formatJSON)
+		}(i)
+
+		go func() {
+			defer wg.Done()
+			_, err := FormatBytes(map[string]int{"a": 1}, &Config{Format: "yml", Registry: r})
+			require.NoError(t, err)
+			r.Names()
+		}()
+	}
+
+	wg.Wait()
+
+	require.Len(t, r.Names(), len(DefaultRegistry.Names())+10)
+}
+
+func TestFormat_registry(t *testing.T) {
+	r := NewRegistry(DefaultRegistry)
+	r.RegisterFormatFunc("custom", func(v interface{}, config *Config) ([]byte, error) {
+		return []byte(config.Format), nil
+	})
+	r.RegisterAlias("c", "custom")
+
+	tests := []formatTestCase{
+		{
+			name: "alias",
+			cfg:  Config{Format: "c", Registry: r},
+			want: "custom",
+		},
+		{
+			name: "parent formatter",
+			cfg:  Config{Format: "yml", Registry: r},
+			v:    map[string]int{"a": 1},
+			want: "a: 1\n",
+		},
+		{
+			name: "options error uses canonical name",
+			cfg:  Config{Format: "yml", Registry: r, FormatOptions: OptionValues{"foo": 1}},
+			err:  fmt.Errorf(`format "yaml" does not support option "foo"`),
+		},
+		{
+			name: "unknown format",
+			cfg:  Config{Format: "custom"},
+			err:  fmt.Errorf(`no formatter for format "custom"`),
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
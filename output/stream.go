@@ -0,0 +1,288 @@
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// ItemEncoder writes formatted items to an underlying writer one at a time.
+type ItemEncoder interface {
+	// Encode formats v and writes the result to the underlying writer.
+	Encode(v interface{}) error
+	// Close flushes any buffered data to the underlying writer. It does not
+	// close the writer itself.
+	Close() error
+}
+
+// StreamFormatter is a Formatter which is also able to format a stream of
+// items as they arrive.
+type StreamFormatter interface {
+	Formatter
+	// NewItemEncoder creates a new ItemEncoder which writes to w. The
+	// ItemEncoder may change behaviour depending on the passed in config.
+	NewItemEncoder(w io.Writer, config *Config) (ItemEncoder, error)
+}
+
+// Encoder formats items one at a time and writes them to an output stream.
+// This is the streaming counterpart of Format and should be used for large
+// or unbounded sets of items.
+type Encoder struct {
+	enc      ItemEncoder
+	selector *selector
+}
+
+// NewEncoder creates a new *Encoder which writes items to w using the given
+// config. Returns an error if there is no formatter for config.Format, if
+// the formatter does not implement StreamFormatter or if config contains
+// transformation stages which need to see all items, like SortBy.
+func NewEncoder(w io.Writer, config *Config) (*Encoder, error) {
+	f, config, err := lookupFormatter(resolveColor(w, config))
+	if err != nil {
+		return nil, err
+	}
+
+	sf, ok := f.(StreamFormatter)
+	if !ok {
+		return nil, fmt.Errorf("format %q does not support streaming", config.Format)
+	}
+
+	s, err := compileSelector(config)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.transform != nil && !s.transform.streamable() {
+		return nil, errors.New("sorting, deduplication, offset and limit are not supported when streaming")
+	}
+
+	enc, err := sf.NewItemEncoder(w, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encoder{enc: enc, selector: s}, nil
+}
+
+// Encode formats v and writes it to the output stream. If the config contains
+// a JSONPointer it is evaluated against each individual item. Items which do
+// not match the Filter of the config are skipped, Select is applied to each
+// item individually.
+func (e *Encoder) Encode(v interface{}) error {
+	v, err := e.selector.pick(v)
+	if err != nil {
+		return err
+	}
+
+	if t := e.selector.transform; t != nil {
+		var ok bool
+
+		v, ok, err = t.applyItem(v)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	return e.enc.Encode(v)
+}
+
+// Close flushes any buffered data to the output stream. Close must be called
+// after the last item was encoded.
+func (e *Encoder) Close() error {
+	return e.enc.Close()
+}
+
+// streamFormatter wraps a FormatFunc and a constructor for an ItemEncoder to
+// implement the StreamFormatter interface. It also implements
+// ConfigurableFormatter and DescribedFormatter.
+type streamFormatter struct {
+	FormatFunc
+	newItemEncoder func(w io.Writer, config *Config) (ItemEncoder, error)
+	options        []Option
+	metadata       Metadata
+}
+
+// Options implements the ConfigurableFormatter interface.
+func (f *streamFormatter) Options() []Option {
+	return f.options
+}
+
+// Metadata implements the DescribedFormatter interface.
+func (f *streamFormatter) Metadata() Metadata {
+	return f.metadata
+}
+
+// NewItemEncoder implements the StreamFormatter interface.
+func (f *streamFormatter) NewItemEncoder(w io.Writer, config *Config) (ItemEncoder, error) {
+	return f.newItemEncoder(w, config)
+}
+
+// separatedEncoder is an ItemEncoder which writes a separator between
+// consecutive items.
+type separatedEncoder struct {
+	w         io.Writer
+	separator string
+	encode    func(w io.Writer, v interface{}) error
+	started   bool
+}
+
+// Encode implements the ItemEncoder interface.
+func (e *separatedEncoder) Encode(v interface{}) error {
+	if e.started && e.separator != "" {
+		if _, err := io.WriteString(e.w, e.separator); err != nil {
+			return err
+		}
+	}
+
+	e.started = true
+
+	return e.encode(e.w, v)
+}
+
+// Close implements the ItemEncoder interface.
+func (e *separatedEncoder) Close() error {
+	return nil
+}
+
+// formatJSONLines formats v as JSON lines. If v is a slice, each item is
+// written on a separate line, otherwise v is written as a single line.
+func formatJSONLines(v interface{}, config *Config) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc, _ := newJSONLinesItemEncoder(&buf, config)
+
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice || !isObjectType(rv.Type()) {
+		if err := enc.Encode(v); err != nil {
+			return nil, err
+		}
+
+		return buf.Bytes(), nil
+	}
+
+	for i := 0; i < rv.Len(); i++ {
+		if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func newJSONLinesItemEncoder(w io.Writer, config *Config) (ItemEncoder, error) {
+	return &separatedEncoder{
+		w: w,
+		encode: func(w io.Writer, v interface{}) error {
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return err
+			}
+
+			if config.Color == ColorAlways {
+				buf = colorizeJSON(buf)
+			}
+
+			_, err = w.Write(append(buf, '\n'))
+			return err
+		},
+	}, nil
+}
+
+func newYAMLItemEncoder(w io.Writer, config *Config) (ItemEncoder, error) {
+	return &separatedEncoder{
+		w:         w,
+		separator: "---\n",
+		encode: func(w io.Writer, v interface{}) error {
+			buf, err := formatYAML(v, config)
+			if err != nil {
+				return err
+			}
+
+			_, err = w.Write(buf)
+			return err
+		},
+	}, nil
+}
+
+func newTemplateItemEncoder(w io.Writer, config *Config) (ItemEncoder, error) {
+	tpl, err := parseTemplate(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &separatedEncoder{
+		w:         w,
+		separator: "\n",
+		encode: func(w io.Writer, v interface{}) error {
+			return executeTemplate(w, tpl, v, config)
+		},
+	}, nil
+}
+
+func newCSVItemEncoder(w io.Writer, config *Config) (ItemEncoder, error) {
+	return newDelimitedItemEncoder(w, config, ',')
+}
+
+func newTSVItemEncoder(w io.Writer, config *Config) (ItemEncoder, error) {
+	return newDelimitedItemEncoder(w, config, '\t')
+}
+
+func newDelimitedItemEncoder(w io.Writer, config *Config, delimiter rune) (ItemEncoder, error) {
+	cw := csv.NewWriter(w)
+	cw.Comma = delimiter
+
+	return &delimitedEncoder{w: cw, columns: config.Columns, wide: config.Wide}, nil
+}
+
+// delimitedEncoder is an ItemEncoder for delimiter separated values. The
+// header is inferred from the configured columns or, if there are none, from
+// the keys of the first item. Keys of subsequent items which are not part of
+// the header are ignored.
+type delimitedEncoder struct {
+	w       *csv.Writer
+	columns []string
+	wide    bool
+	// keys contains the column keys of the header once it was written.
+	keys []string
+}
+
+// Encode implements the ItemEncoder interface.
+func (e *delimitedEncoder) Encode(v interface{}) error {
+	columns := e.keys
+	if columns == nil {
+		columns = e.columns
+	}
+
+	t, err := newTabular(v, columns, e.wide)
+	if err != nil {
+		return err
+	}
+
+	if e.keys == nil {
+		if len(t.keys) == 0 {
+			return nil
+		}
+
+		e.keys = t.keys
+
+		if err := e.w.Write(t.headerNames(nil)); err != nil {
+			return err
+		}
+	}
+
+	if err := e.w.WriteAll(stringRows(t.rows)); err != nil {
+		return err
+	}
+
+	return e.w.Error()
+}
+
+// Close implements the ItemEncoder interface.
+func (e *delimitedEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,119 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncoder(t *testing.T) {
+	items := []interface{}{
+		map[string]interface{}{"name": "foo", "nested": map[string]interface{}{"count": 1}},
+		map[string]interface{}{"name": "bar", "extra": true},
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		want string
+		err  error
+	}{
+		{
+			name: "unknown format",
+			cfg:  Config{Format: "none"},
+			err:  errors.New(`no formatter for format "none"`),
+		},
+		{
+			name: "formatter does not support streaming",
+			cfg:  Config{Format: "json"},
+			err:  errors.New(`format "json" does not support streaming`),
+		},
+		{
+			name: "json-lines",
+			cfg:  Config{Format: "json-lines"},
+			want: "{\"name\":\"foo\",\"nested\":{\"count\":1}}\n{\"extra\":true,\"name\":\"bar\"}\n",
+		},
+		{
+			name: "json-lines with json pointer",
+			cfg:  Config{Format: "json-lines", JSONPointer: "/name"},
+			want: "\"foo\"\n\"bar\"\n",
+		},
+		{
+			name: "json-lines with filter and select",
+			cfg:  Config{Format: "json-lines", Filter: "@.extra", Select: []string{"name"}},
+			want: "{\"name\":\"bar\"}\n",
+		},
+		{
+			name: "sorting is not supported",
+			cfg:  Config{Format: "json-lines", SortBy: "name"},
+			err:  errors.New("sorting, deduplication, offset and limit are not supported when streaming"),
+		},
+		{
+			name: "yaml",
+			cfg:  Config{Format: "yaml"},
+			want: "name: foo\nnested:\n  count: 1\n---\nextra: true\nname: bar\n",
+		},
+		{
+			name: "csv infers header from first item",
+			cfg:  Config{Format: "csv"},
+			want: "name,nested\nfoo,\"{\"\"count\"\":1}\"\nbar,\n",
+		},
+		{
+			name: "tsv with columns",
+			cfg:  Config{Format: "tsv", Columns: []string{"name", "extra"}},
+			want: "name\textra\nfoo\t\nbar\ttrue\n",
+		},
+		{
+			name: "gotemplate requires template",
+			cfg:  Config{Format: "gotemplate"},
+			err:  errors.New("template must not be empty"),
+		},
+		{
+			name: "gotemplate",
+			cfg:  Config{Format: "gotemplate={{.name}}"},
+			want: "foo\nbar",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			enc, err := NewEncoder(&buf, &test.cfg)
+			if test.err != nil {
+				require.EqualError(t, err, test.err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+
+			for _, item := range items {
+				require.NoError(t, enc.Encode(item))
+			}
+
+			require.NoError(t, enc.Close())
+			require.Equal(t, test.want, buf.String())
+		})
+	}
+}
+
+func TestFormatJSONLines(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "single value",
+			cfg:  Config{Format: "json-lines"},
+			v:    map[string]interface{}{"foo": "bar"},
+			want: "{\"foo\":\"bar\"}\n",
+		},
+		{
+			name: "slice",
+			cfg:  Config{Format: "json-lines"},
+			v:    []interface{}{1, "two", map[string]interface{}{"three": 3}},
+			want: "1\n\"two\"\n{\"three\":3}\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,144 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// columnSeparator separates the columns of tables.
+const columnSeparator = "   "
+
+var ansiEscapeRegexp = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]`)
+
+func formatTable(v interface{}, config *Config) ([]byte, error) {
+	t, err := newTabular(v, config.Columns, config.Wide)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(t.keys) == 0 {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+
+	if t.object {
+		// Single objects are rendered as key/value rows without header.
+		rows := make([][]string, len(t.keys))
+		for i, name := range t.headerNames(nil) {
+			rows[i] = []string{name, stringify(t.rows[0][i])}
+		}
+
+		writeTable(&buf, nil, rows, nil)
+
+		return buf.Bytes(), nil
+	}
+
+	writeTable(&buf, t.headerNames(strings.ToUpper), stringRows(t.rows), numericColumns(t))
+
+	return buf.Bytes(), nil
+}
+
+// stringRows converts the normalized cell values of rows into strings.
+func stringRows(rows [][]interface{}) [][]string {
+	srows := make([][]string, len(rows))
+
+	for i, row := range rows {
+		srows[i] = make([]string, len(row))
+		for j, cell := range row {
+			srows[i][j] = stringify(cell)
+		}
+	}
+
+	return srows
+}
+
+// numericColumns returns a slice which indicates for each column of t whether
+// it contains only numeric values. Columns without any values are not
+// considered numeric.
+func numericColumns(t *tabular) []bool {
+	numeric := make([]bool, len(t.keys))
+	for i := range t.keys {
+		numeric[i] = isNumericColumn(t.rows, i)
+	}
+
+	return numeric
+}
+
+func isNumericColumn(rows [][]interface{}, column int) bool {
+	numeric := false
+
+	for _, row := range rows {
+		switch row[column].(type) {
+		case nil:
+		case json.Number:
+			numeric = true
+		default:
+			return false
+		}
+	}
+
+	return numeric
+}
+
+// writeTable writes header and rows as aligned columns to buf. Header is
+// omitted if nil. Columns for which rightAlign is true are aligned to the
+// right. Column widths are calculated ignoring ANSI escape sequences.
+func writeTable(buf *bytes.Buffer, header []string, rows [][]string, rightAlign []bool) {
+	if header != nil {
+		rows = append([][]string{header}, rows...)
+	}
+
+	var widths []int
+
+	for _, row := range rows {
+		for i, cell := range row {
+			if i == len(widths) {
+				widths = append(widths, 0)
+			}
+
+			if w := displayWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	var line strings.Builder
+
+	for _, row := range rows {
+		line.Reset()
+
+		for i, cell := range row {
+			if i > 0 {
+				line.WriteString(columnSeparator)
+			}
+
+			padding := strings.Repeat(" ", widths[i]-displayWidth(cell))
+
+			if i < len(rightAlign) && rightAlign[i] {
+				line.WriteString(padding)
+				line.WriteString(cell)
+			} else {
+				line.WriteString(cell)
+				line.WriteString(padding)
+			}
+		}
+
+		// Avoid trailing whitespace caused by padding of empty cells.
+		buf.WriteString(strings.TrimRight(line.String(), " "))
+		buf.WriteByte('\n')
+	}
+}
+
+// displayWidth returns the number of runes in s that are displayed on a
+// terminal, ignoring ANSI escape sequences.
+func displayWidth(s string) int {
+	if strings.IndexByte(s, '\x1b') != -1 {
+		s = ansiEscapeRegexp.ReplaceAllString(s, "")
+	}
+
+	return utf8.RuneCountInString(s)
+}
@@ -0,0 +1,101 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/mgutz/ansi"
+)
+
+type tableTestItem struct {
+	Name    string            `json:"name"`
+	Age     int               `json:"age"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	private string
+}
+
+func TestFormatTable(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "nil",
+			cfg:  Config{Format: "table"},
+			want: "",
+		},
+		{
+			name: "scalar",
+			cfg:  Config{Format: "table"},
+			v:    "foo",
+			want: "VALUE\nfoo\n",
+		},
+		{
+			name: "slice of scalars",
+			cfg:  Config{Format: "table"},
+			v:    []interface{}{"foo", 42, true},
+			want: "VALUE\nfoo\n42\ntrue\n",
+		},
+		{
+			name: "slice of maps",
+			cfg:  Config{Format: "table"},
+			v: []map[string]interface{}{
+				{"name": "foo", "count": 1},
+				{"name": "barbaz", "count": 1000, "extra": true},
+			},
+			want: `COUNT   NAME     EXTRA
+    1   foo
+ 1000   barbaz   true
+`,
+		},
+		{
+			name: "slice of structs uses field order",
+			cfg:  Config{Format: "table"},
+			v: []tableTestItem{
+				{Name: "foo", Age: 42, Labels: map[string]string{"a": "b"}},
+				{Name: "bar", Age: 7, private: "secret"},
+			},
+			want: `NAME   AGE   LABELS
+foo     42   {"a":"b"}
+bar      7
+`,
+		},
+		{
+			name: "empty slice of structs renders header",
+			cfg:  Config{Format: "table"},
+			v:    []*tableTestItem{},
+			want: "NAME   AGE   LABELS\n",
+		},
+		{
+			name: "column selection",
+			cfg:  Config{Format: "table", Columns: []string{"AGE", "name", "missing"}},
+			v: []tableTestItem{
+				{Name: "foo", Age: 42},
+				{Name: "bar", Age: 7},
+			},
+			want: `AGE   NAME   MISSING
+ 42   foo
+  7   bar
+`,
+		},
+		{
+			name: "single object",
+			cfg:  Config{Format: "table"},
+			v:    &tableTestItem{Name: "foo", Age: 42},
+			want: "name     foo\nage      42\nlabels\n",
+		},
+		{
+			name: "single object column selection",
+			cfg:  Config{Format: "table", Columns: []string{"age"}},
+			v:    map[string]interface{}{"name": "foo", "age": 42},
+			want: "age   42\n",
+		},
+		{
+			name: "ansi aware column widths",
+			cfg:  Config{Format: "table"},
+			v: []map[string]interface{}{
+				{"name": ansi.Color("foo", "red"), "value": "a"},
+				{"name": "foobar", "value": "b"},
+			},
+			want: "NAME     VALUE\n" + ansi.Color("foo", "red") + "      a\nfoobar   b\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,313 @@
+package output
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// valueKey is the column key used for values that are not objects.
+const valueKey = "value"
+
+// tabular is an intermediate representation of values that are rendered as
+// rows and columns by table-like formatters.
+type tabular struct {
+	// keys contains the keys of the columns.
+	keys []string
+	// headers contains the column headers configured via `output` struct
+	// tags. Columns without a configured header have an empty header.
+	headers []string
+	// rows contains the normalized cell values of each row. Each row contains
+	// exactly len(keys) cells.
+	rows [][]interface{}
+	// object is true if the tabular data was created from a single map or
+	// struct. In this case keys contains the object keys and there is
+	// exactly one row containing the values.
+	object bool
+}
+
+// newTabular converts v into its tabular representation. Slices of maps or
+// structs produce one row per item with one column per key. Single maps or
+// structs produce a single row and have the object field set. All other
+// values produce rows with a single value column.
+//
+// Columns of struct fields respect the `output` struct tag: fields tagged
+// with "-" are excluded, wide fields are only included if wide is true and
+// omitempty fields are excluded if they are empty in all rows.
+//
+// If columns is non-empty, only the columns matching the given keys or
+// headers are included in the order they were provided, regardless of their
+// struct tags. Columns are matched case-insensitively.
+func newTabular(v interface{}, columns []string, wide bool) (*tabular, error) {
+	rv := indirect(reflect.ValueOf(v))
+
+	switch rv.Kind() {
+	case reflect.Invalid, reflect.Ptr, reflect.Interface:
+		return newObjectsTabular(nil, nil, nil, columns, wide), nil
+	case reflect.Slice, reflect.Array:
+		if !isObjectType(rv.Type()) {
+			break
+		}
+
+		return newSliceTabular(rv, columns, wide)
+	case reflect.Map, reflect.Struct:
+		if !isObjectType(rv.Type()) {
+			break
+		}
+
+		return newObjectTabular(v, columns, wide)
+	}
+
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tabular{
+		keys:    []string{valueKey},
+		headers: []string{""},
+		rows:    [][]interface{}{{nv}},
+	}, nil
+}
+
+func newSliceTabular(rv reflect.Value, columns []string, wide bool) (*tabular, error) {
+	var keys keySet
+
+	fields := make(fieldSet)
+
+	// Seed the keys from the static element type so that empty slices still
+	// produce headers.
+	if et := indirectType(rv.Type().Elem()); et.Kind() == reflect.Struct && isObjectType(et) {
+		keys.add(structFieldKeys(et)...)
+		fields.addType(et)
+	}
+
+	n := rv.Len()
+	items := make([]interface{}, n)
+	scalar := false
+
+	for i := 0; i < n; i++ {
+		item := rv.Index(i).Interface()
+
+		nv, err := normalize(item)
+		if err != nil {
+			return nil, err
+		}
+
+		items[i] = nv
+
+		m, ok := nv.(map[string]interface{})
+		if !ok {
+			scalar = scalar || nv != nil
+			continue
+		}
+
+		if objKeys, ok := objectKeys(item); ok {
+			keys.add(objKeys...)
+			fields.addType(reflect.TypeOf(item))
+		}
+
+		keys.add(sortedKeys(m)...)
+	}
+
+	if scalar {
+		// Mixed slices or slices of scalar values are rendered as a single
+		// column.
+		rows := make([][]interface{}, n)
+		for i, item := range items {
+			rows[i] = []interface{}{item}
+		}
+
+		return &tabular{keys: []string{valueKey}, headers: []string{""}, rows: rows}, nil
+	}
+
+	objects := make([]map[string]interface{}, n)
+	for i, item := range items {
+		objects[i], _ = item.(map[string]interface{})
+	}
+
+	return newObjectsTabular(keys.keys, fields, objects, columns, wide), nil
+}
+
+func newObjectTabular(v interface{}, columns []string, wide bool) (*tabular, error) {
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m, _ := nv.(map[string]interface{})
+
+	var keys keySet
+
+	objKeys, _ := objectKeys(v)
+	keys.add(objKeys...)
+	keys.add(sortedKeys(m)...)
+
+	fields := make(fieldSet)
+	fields.addType(reflect.TypeOf(v))
+
+	t := newObjectsTabular(keys.keys, fields, []map[string]interface{}{m}, columns, wide)
+	t.object = true
+
+	return t, nil
+}
+
+// newObjectsTabular creates a tabular with one row per object. Keys contains
+// all known object keys and fields their struct field metadata.
+func newObjectsTabular(keys []string, fields fieldSet, objects []map[string]interface{}, columns []string, wide bool) *tabular {
+	if len(columns) > 0 {
+		keys = selectColumns(keys, fields, columns)
+	} else {
+		keys = visibleKeys(keys, fields, objects, wide)
+	}
+
+	t := &tabular{
+		keys:    keys,
+		headers: make([]string, len(keys)),
+		rows:    make([][]interface{}, len(objects)),
+	}
+
+	for i, key := range keys {
+		t.headers[i] = fields[key].name
+	}
+
+	for i, m := range objects {
+		row := make([]interface{}, len(keys))
+		for j, key := range keys {
+			row[j] = m[key]
+		}
+
+		t.rows[i] = row
+	}
+
+	return t
+}
+
+// headerNames returns the column headers of t. Columns without a header
+// configured via struct tag are named after their key transformed by fn, or
+// the key itself if fn is nil.
+func (t *tabular) headerNames(fn func(string) string) []string {
+	names := make([]string, len(t.keys))
+
+	for i, key := range t.keys {
+		switch {
+		case t.headers != nil && t.headers[i] != "":
+			names[i] = t.headers[i]
+		case fn != nil:
+			names[i] = fn(key)
+		default:
+			names[i] = key
+		}
+	}
+
+	return names
+}
+
+// visibleKeys filters keys according to the struct tags of the
+// corresponding fields.
+func visibleKeys(keys []string, fields fieldSet, objects []map[string]interface{}, wide bool) []string {
+	visible := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		field := fields[key]
+
+		switch {
+		case field.hidden:
+		case field.wide && !wide:
+		case field.omitEmpty && isEmptyColumn(objects, key):
+		default:
+			visible = append(visible, key)
+		}
+	}
+
+	return visible
+}
+
+func isEmptyColumn(objects []map[string]interface{}, key string) bool {
+	for _, m := range objects {
+		if !isEmptyValue(m[key]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isObjectType returns true if values of t are encoded as JSON objects or
+// arrays by encoding/json, that is, if neither t nor *t implement custom
+// marshalers and t is a map, struct, slice or array type. Byte slices are not
+// considered to be objects because they are encoded as strings.
+func isObjectType(t reflect.Type) bool {
+	t = indirectType(t)
+
+	switch t.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Array:
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return false
+		}
+	default:
+		return false
+	}
+
+	pt := reflect.PtrTo(t)
+
+	return !pt.Implements(jsonMarshalerType) && !pt.Implements(textMarshalerType)
+}
+
+// selectColumns resolves columns to the matching keys. A column matches a key
+// if it is equal to the key or the header of the corresponding field. Columns
+// without a match are returned as is.
+func selectColumns(keys []string, fields fieldSet, columns []string) []string {
+	selected := make([]string, len(columns))
+
+	for i, column := range columns {
+		selected[i] = column
+
+		for _, key := range keys {
+			if strings.EqualFold(key, column) || fields[key].name != "" && strings.EqualFold(fields[key].name, column) {
+				selected[i] = key
+				break
+			}
+		}
+	}
+
+	return selected
+}
+
+// keySet is an ordered set of keys.
+type keySet struct {
+	keys []string
+	seen map[string]bool
+}
+
+// add adds keys that are not already present to the set.
+func (s *keySet) add(keys ...string) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+
+	for _, key := range keys {
+		if !s.seen[key] {
+			s.seen[key] = true
+			s.keys = append(s.keys, key)
+		}
+	}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
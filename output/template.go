@@ -3,19 +3,160 @@ package output
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"text/template"
 )
 
-func formatTemplate(buf *bytes.Buffer, v interface{}, config *Config) error {
-	if config.Template == "" {
-		return errors.New("template must not be empty")
+// mainTemplateName is the name of the template parsed from Config.Template.
+const mainTemplateName = "template"
+
+// parseTemplate parses the templates configured in config and returns the
+// template that should be executed. Templates of compiled configs are parsed
+// only once.
+func parseTemplate(config *Config) (*template.Template, error) {
+	if config.templateCache != nil {
+		return config.templateCache.parse(config)
+	}
+
+	return parseTemplateUncached(config)
+}
+
+func parseTemplateUncached(config *Config) (*template.Template, error) {
+	if config.Template == "" && len(config.TemplateFiles) == 0 {
+		return nil, errors.New("template must not be empty")
+	}
+
+	tpl := template.New(mainTemplateName).Option(config.TemplateConfig.Options...)
+
+	if config.TemplateConfig.Strict {
+		tpl = tpl.Option("missingkey=error")
+	}
+
+	if config.TemplateConfig.DefaultFuncs {
+		tpl = tpl.Funcs(DefaultTemplateFuncs())
+	}
+
+	tpl = tpl.Funcs(config.TemplateConfig.Funcs)
+
+	if err := parseTemplateFiles(tpl, config); err != nil {
+		return nil, newTemplateError(err, tpl, nil, config)
+	}
+
+	name := config.TemplateName
+
+	if config.Template != "" {
+		text, err := readTemplate(config)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tpl.Parse(text); err != nil {
+			return nil, newTemplateError(err, tpl, nil, config)
+		}
+
+		if name == "" {
+			name = mainTemplateName
+		}
+	}
+
+	if name == "" {
+		first, err := firstTemplateFile(config)
+		if err != nil {
+			return nil, err
+		}
+
+		name = first
+	}
+
+	if t := tpl.Lookup(name); t != nil {
+		return t, nil
+	}
+
+	return nil, fmt.Errorf("template %q not defined", name)
+}
+
+// parseTemplateFiles parses all files matching the patterns in
+// config.TemplateFiles into tpl.
+func parseTemplateFiles(tpl *template.Template, config *Config) error {
+	if len(config.TemplateFiles) == 0 {
+		return nil
+	}
+
+	if config.TemplateFS != nil {
+		_, err := tpl.ParseFS(config.TemplateFS, config.TemplateFiles...)
+		return err
+	}
+
+	for _, pattern := range config.TemplateFiles {
+		if _, err := tpl.ParseGlob(pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// firstTemplateFile returns the base name of the first file matching the
+// first pattern in config.TemplateFiles.
+func firstTemplateFile(config *Config) (string, error) {
+	var (
+		matches []string
+		err     error
+	)
+
+	pattern := config.TemplateFiles[0]
+
+	if config.TemplateFS != nil {
+		matches, err = fs.Glob(config.TemplateFS, pattern)
+	} else {
+		matches, err = filepath.Glob(pattern)
+	}
+
+	if err != nil {
+		return "", err
 	}
 
-	tpl, err := template.New("template").
-		Option(config.TemplateConfig.Options...).
-		Funcs(config.TemplateConfig.Funcs).
-		Parse(config.Template)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("pattern matches no files: %#q", pattern)
+	}
+
+	return filepath.Base(matches[0]), nil
+}
+
+// readTemplate returns the text of config.Template. If it starts with an @,
+// the template text is read from the referenced file.
+func readTemplate(config *Config) (string, error) {
+	if !strings.HasPrefix(config.Template, "@") {
+		return config.Template, nil
+	}
+
+	path := config.Template[1:]
+
+	var (
+		buf []byte
+		err error
+	)
+
+	if config.TemplateFS != nil {
+		buf, err = fs.ReadFile(config.TemplateFS, path)
+	} else {
+		buf, err = os.ReadFile(path)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("failed to read template: %w", err)
+	}
+
+	return string(buf), nil
+}
+
+func formatTemplate(buf *bytes.Buffer, v interface{}, config *Config) error {
+	tpl, err := parseTemplate(config)
 	if err != nil {
 		return err
 	}
@@ -28,7 +169,7 @@ func formatTemplate(buf *bytes.Buffer, v interface{}, config *Config) error {
 		for i := 0; i < n; i++ {
 			v := rv.Index(i).Interface()
 
-			if err := tpl.Execute(buf, v); err != nil {
+			if err := executeTemplate(buf, tpl, v, config); err != nil {
 				return err
 			}
 
@@ -40,5 +181,5 @@ func formatTemplate(buf *bytes.Buffer, v interface{}, config *Config) error {
 		return nil
 	}
 
-	return tpl.Execute(buf, v)
+	return executeTemplate(buf, tpl, v, config)
 }
@@ -0,0 +1,628 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode/utf8"
+)
+
+var (
+	// templateErrorRegexp matches the location prefix of parse and exec
+	// errors produced by text/template, e.g. `template: name:1:7: ...`.
+	// The column is only present for exec errors.
+	templateErrorRegexp = regexp.MustCompile(`(?s)^template: ([^:]+):(\d+)(?::(\d+))?: (.*)$`)
+
+	// templateExecRegexp matches the message of exec errors, e.g.
+	// `executing "name" at <.foo>: map has no entry for key "foo"`.
+	templateExecRegexp = regexp.MustCompile(`(?s)^executing "([^"]*)" at <(.*?)>: (.*)$`)
+
+	// templateFieldRegexp matches field chains like `.foo.bar` or
+	// `$.foo.bar`.
+	templateFieldRegexp = regexp.MustCompile(`^\$?(\.[A-Za-z0-9_]+)+$`)
+)
+
+// TemplateError is returned by template-based formatters if a template
+// cannot be parsed or executed. It provides context about the location of
+// the error in the template source and the data available at that point.
+type TemplateError struct {
+	// Name is the name of the template or template file that contains the
+	// error. Errors in the template configured via Config.Template are
+	// reported as "template".
+	Name string
+	// Line is the line of the error in the template source, starting at 1.
+	Line int
+	// Column is the column of the error in the template source, starting at
+	// 1. It counts characters rather than bytes. Zero if unknown, which is the
+	// case for parse errors.
+	Column int
+	// Source is the source line containing the error. Empty if unknown.
+	Source string
+	// Message describes the error without location information, e.g.
+	// `map has no entry for key "nmae"`.
+	Message string
+	// Keys contains the keys of the map or the fields of the struct a
+	// missing key or field was looked up in. Empty if unknown.
+	Keys []string
+	// Suggestions contains keys which are similar to the missing key or
+	// field.
+	Suggestions []string
+	// Err is the original error returned by text/template.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *TemplateError) Error() string {
+	var sb strings.Builder
+
+	sb.WriteString("template")
+
+	if e.Name != mainTemplateName {
+		fmt.Fprintf(&sb, " %q", e.Name)
+	}
+
+	fmt.Fprintf(&sb, ": line %d", e.Line)
+
+	if e.Column > 0 {
+		fmt.Fprintf(&sb, ", column %d", e.Column)
+	}
+
+	fmt.Fprintf(&sb, ": %s", e.Message)
+
+	if e.Source != "" {
+		gutter := strconv.Itoa(e.Line)
+
+		fmt.Fprintf(&sb, "\n  %s | %s", gutter, e.Source)
+
+		if e.Column > 0 && e.Column <= utf8.RuneCountInString(e.Source)+1 {
+			prefix := []rune(e.Source)[:e.Column-1]
+			fmt.Fprintf(&sb, "\n  %s | %s^", strings.Repeat(" ", len(gutter)), caretPadding(string(prefix)))
+		}
+	}
+
+	if len(e.Keys) > 0 {
+		fmt.Fprintf(&sb, "\navailable keys: %s", strings.Join(e.Keys, ", "))
+	}
+
+	if len(e.Suggestions) > 0 {
+		fmt.Fprintf(&sb, "\ndid you mean %s?", quoteJoin(e.Suggestions, " or "))
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the original error.
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// caretPadding returns the whitespace to print before a caret which points at
+// the character following prefix. Tabs are retained to keep the alignment.
+func caretPadding(prefix string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+
+		return ' '
+	}, prefix)
+}
+
+func quoteJoin(values []string, sep string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+
+	return strings.Join(quoted, sep)
+}
+
+// executeTemplate executes tpl with data and writes the result to w. Errors
+// are converted into a *TemplateError if possible.
+func executeTemplate(w io.Writer, tpl *template.Template, data interface{}, config *Config) error {
+	if err := tpl.Execute(w, data); err != nil {
+		return newTemplateError(err, tpl, data, config)
+	}
+
+	return nil
+}
+
+// newTemplateError creates a *TemplateError from err which was returned by
+// parsing or executing tpl. Data is the value tpl was executed with and is
+// used to determine the available keys for missing key or field errors. It
+// is nil for parse errors. Returns err unchanged if it does not contain
+// location information.
+func newTemplateError(err error, tpl *template.Template, data interface{}, config *Config) error {
+	m := templateErrorRegexp.FindStringSubmatch(err.Error())
+	if m == nil {
+		return err
+	}
+
+	e := &TemplateError{Name: m[1], Message: m[4], Err: err}
+	e.Line, _ = strconv.Atoi(m[2])
+
+	var (
+		node     string
+		execName string
+		// byteCol is the one-based column of the error in bytes.
+		byteCol int
+	)
+
+	if m[3] != "" {
+		// The column reported by text/template is a zero-based byte offset.
+		col, _ := strconv.Atoi(m[3])
+		byteCol = col + 1
+		e.Column = byteCol
+	}
+
+	if x := templateExecRegexp.FindStringSubmatch(e.Message); x != nil {
+		execName, node, e.Message = x[1], x[2], x[3]
+	}
+
+	// Errors in templates created via define are reported with the name of
+	// the template or file the block was parsed from.
+	source, ok := templateSource(config, e.Name)
+	if ok {
+		lines := strings.Split(source, "\n")
+		if e.Line > 0 && e.Line <= len(lines) {
+			e.Source = strings.TrimSuffix(lines[e.Line-1], "\r")
+
+			if byteCol > 0 && byteCol <= len(e.Source)+1 {
+				e.Column = utf8.RuneCountInString(e.Source[:byteCol-1]) + 1
+			}
+		}
+	}
+
+	if node == "" || execName != tpl.Name() || !ok {
+		return e
+	}
+
+	keys, missing, ok := lookupTemplateKeys(tpl, source, e, byteCol, node, data)
+	if !ok {
+		return e
+	}
+
+	e.Keys = keys
+	e.Suggestions = suggest(missing, keys)
+
+	return e
+}
+
+// lookupTemplateKeys resolves the field chain node, e.g. `.foo.bar`, against
+// the value of dot at the location of the error and returns the keys of the
+// map or struct in which a key or field is missing alongside the name of the
+// missing key. Within range and with actions, dot is resolved from their
+// pipelines if these are plain field chains. Inside of range, the chain is
+// resolved against the items in order and the first item that lacks the key
+// is reported, since execution stops there. Returns false if the chain
+// cannot be resolved reliably, e.g. because dot is set by a pipeline with
+// function calls.
+func lookupTemplateKeys(tpl *template.Template, source string, e *TemplateError, byteCol int, node string, data interface{}) ([]string, string, bool) {
+	if !templateFieldRegexp.MatchString(node) {
+		return nil, "", false
+	}
+
+	root := reflect.ValueOf(data)
+	dots := []reflect.Value{root}
+
+	if !strings.HasPrefix(node, "$") {
+		offset, ok := sourceOffset(source, e.Line, byteCol)
+		if !ok || tpl.Tree == nil {
+			return nil, "", false
+		}
+
+		dots, ok = dotAt(tpl.Tree.Root, offset, root, dots)
+		if !ok {
+			return nil, "", false
+		}
+	}
+
+	names := strings.Split(strings.TrimPrefix(node, "$")[1:], ".")
+
+	for _, dot := range dots {
+		if keys, missing, ok := missingKey(dot, names, e.Message); ok {
+			return keys, missing, true
+		}
+	}
+
+	return nil, "", false
+}
+
+// missingKey resolves the field chain names against v. If a key or field is
+// missing which is mentioned in message, the keys of the map or struct it
+// was looked up in are returned alongside its name.
+func missingKey(v reflect.Value, names []string, message string) ([]string, string, bool) {
+	for _, name := range names {
+		v = indirectValue(v)
+
+		next, ok := fieldValue(v, name)
+		if !ok {
+			keys := valueKeys(v)
+			if keys == nil || !strings.Contains(message, name) {
+				return nil, "", false
+			}
+
+			return keys, name, true
+		}
+
+		v = next
+	}
+
+	return nil, "", false
+}
+
+// fieldValue returns the value of the map key or struct field name of v.
+func fieldValue(v reflect.Value, name string) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return reflect.Value{}, false
+		}
+
+		fv := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		return fv, fv.IsValid()
+	case reflect.Struct:
+		fv := v.FieldByName(name)
+		return fv, fv.IsValid()
+	default:
+		return reflect.Value{}, false
+	}
+}
+
+// valueKeys returns the sorted keys of the map v or the exported field and
+// method names of the struct v. Returns nil for any other kind.
+func valueKeys(v reflect.Value) []string {
+	var keys []string
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+
+		keys = make([]string, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			keys = append(keys, key.String())
+		}
+	case reflect.Struct:
+		t := v.Type()
+
+		keys = make([]string, 0, t.NumField()+t.NumMethod())
+		for i := 0; i < t.NumField(); i++ {
+			if f := t.Field(i); f.PkgPath == "" {
+				keys = append(keys, f.Name)
+			}
+		}
+
+		for i := 0; i < t.NumMethod(); i++ {
+			keys = append(keys, t.Method(i).Name)
+		}
+	default:
+		return nil
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func indirectValue(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// sourceOffset converts a line and a one-based column into a byte offset in
+// source.
+func sourceOffset(source string, line, column int) (int, bool) {
+	if column == 0 {
+		return 0, false
+	}
+
+	offset := 0
+
+	for i := 1; i < line; i++ {
+		n := strings.IndexByte(source[offset:], '\n')
+		if n < 0 {
+			return 0, false
+		}
+
+		offset += n + 1
+	}
+
+	return offset + column - 1, true
+}
+
+// dotAt returns the possible values of dot for the field node at offset
+// given that dots are the possible values of dot at node. Root is the data
+// the template was executed with. Returns false if offset does not point at
+// a field node or if dot cannot be determined there. The result is empty if
+// the field node is never evaluated, e.g. in the body of a range over an
+// empty slice.
+func dotAt(node parse.Node, offset int, root reflect.Value, dots []reflect.Value) ([]reflect.Value, bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return nil, false
+		}
+
+		for _, child := range n.Nodes {
+			if d, ok := dotAt(child, offset, root, dots); ok {
+				return d, true
+			}
+		}
+	case *parse.ActionNode:
+		return dotAt(n.Pipe, offset, root, dots)
+	case *parse.TemplateNode:
+		return dotAt(n.Pipe, offset, root, dots)
+	case *parse.IfNode:
+		return branchDotAt(&n.BranchNode, offset, root, dots, dots)
+	case *parse.RangeNode:
+		var items []reflect.Value
+
+		for _, v := range pipeValues(n.Pipe, root, dots) {
+			items = append(items, rangeItems(v)...)
+		}
+
+		return branchDotAt(&n.BranchNode, offset, root, dots, items)
+	case *parse.WithNode:
+		return branchDotAt(&n.BranchNode, offset, root, dots, pipeValues(n.Pipe, root, dots))
+	case *parse.PipeNode:
+		if n == nil {
+			return nil, false
+		}
+
+		for _, cmd := range n.Cmds {
+			for _, arg := range cmd.Args {
+				if d, ok := dotAt(arg, offset, root, dots); ok {
+					return d, true
+				}
+			}
+		}
+	case *parse.FieldNode:
+		pos := int(n.Position())
+		if offset >= pos && offset < pos+len(n.String()) {
+			return dots, dots != nil
+		}
+	}
+
+	return nil, false
+}
+
+// branchDotAt is like dotAt for if, range and with actions. Body contains
+// the possible values of dot in the body of the action, which is nil if
+// they are unknown.
+func branchDotAt(n *parse.BranchNode, offset int, root reflect.Value, dots, body []reflect.Value) ([]reflect.Value, bool) {
+	if d, ok := dotAt(n.Pipe, offset, root, dots); ok {
+		return d, true
+	}
+
+	if n.List != nil {
+		if body == nil {
+			// Mark the values as unknown but still look for offset, so
+			// that the search stops there.
+			if _, ok := dotAt(n.List, offset, root, []reflect.Value{}); ok {
+				return nil, false
+			}
+		} else if d, ok := dotAt(n.List, offset, root, body); ok {
+			return d, true
+		}
+	}
+
+	if n.ElseList != nil {
+		return dotAt(n.ElseList, offset, root, dots)
+	}
+
+	return nil, false
+}
+
+// pipeValues evaluates pipe for each of the possible values of dot if it is
+// a plain field chain like `.foo.bar`, `$.foo` or `.`, optionally with
+// variable declarations. Returns nil if the pipeline is more complex.
+func pipeValues(pipe *parse.PipeNode, root reflect.Value, dots []reflect.Value) []reflect.Value {
+	if pipe == nil || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return nil
+	}
+
+	var (
+		names    []string
+		fromRoot bool
+	)
+
+	switch arg := pipe.Cmds[0].Args[0].(type) {
+	case *parse.DotNode:
+	case *parse.FieldNode:
+		names = arg.Ident
+	case *parse.VariableNode:
+		if arg.Ident[0] != "$" {
+			return nil
+		}
+
+		names, fromRoot = arg.Ident[1:], true
+	default:
+		return nil
+	}
+
+	if fromRoot {
+		dots = []reflect.Value{root}
+	}
+
+	values := []reflect.Value{}
+
+	for _, v := range dots {
+		ok := true
+
+		for _, name := range names {
+			if v, ok = fieldValue(indirectValue(v), name); !ok {
+				break
+			}
+		}
+
+		if ok {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+// rangeItems returns the items range iterates over for v. Map values are
+// returned in the order of their sorted keys, like range visits them.
+func rangeItems(v reflect.Value) []reflect.Value {
+	v = indirectValue(v)
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		items := make([]reflect.Value, v.Len())
+		for i := range items {
+			items[i] = v.Index(i)
+		}
+
+		return items
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].String() < keys[j].String()
+		})
+
+		items := make([]reflect.Value, len(keys))
+		for i, key := range keys {
+			items[i] = v.MapIndex(key)
+		}
+
+		return items
+	default:
+		return nil
+	}
+}
+
+// templateSource returns the source of the template which was parsed under
+// name. This is either the text of config.Template or the content of one of
+// the files matching config.TemplateFiles.
+func templateSource(config *Config, name string) (string, bool) {
+	if name == mainTemplateName && config.Template != "" {
+		text, err := readTemplate(config)
+		return text, err == nil
+	}
+
+	for _, pattern := range config.TemplateFiles {
+		var (
+			matches []string
+			buf     []byte
+			err     error
+		)
+
+		if config.TemplateFS != nil {
+			matches, _ = fs.Glob(config.TemplateFS, pattern)
+		} else {
+			matches, _ = filepath.Glob(pattern)
+		}
+
+		for _, match := range matches {
+			if filepath.Base(match) != name {
+				continue
+			}
+
+			if config.TemplateFS != nil {
+				buf, err = fs.ReadFile(config.TemplateFS, match)
+			} else {
+				buf, err = os.ReadFile(match)
+			}
+
+			return string(buf), err == nil
+		}
+	}
+
+	return "", false
+}
+
+// suggest returns the candidates which are most similar to name. Candidates
+// are considered if they only differ in case or if their edit distance to
+// name is below a limit which grows with the length of name.
+func suggest(name string, candidates []string) []string {
+	var (
+		suggestions []string
+		limit       = utf8.RuneCountInString(name)/3 + 2
+		best        = -1
+	)
+
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+
+		switch {
+		case d >= limit:
+			continue
+		case best < 0 || d < best:
+			best = d
+			suggestions = []string{c}
+		case d == best:
+			suggestions = append(suggestions, c)
+		}
+	}
+
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b, with the
+// transposition of adjacent characters counting as a single edit. Typos like
+// "nmae" for "name" thus have a distance of one.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+func minInt(values ...int) int {
+	min := values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+	}
+
+	return min
+}
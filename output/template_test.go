@@ -0,0 +1,280 @@
+package output
+
+import (
+	"embed"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed testdata/templates
+var templatesFS embed.FS
+
+func TestFormatTemplateFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tmpl"), []byte(`{{template "greet" .}}!`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "greet.tmpl"), []byte(`{{define "greet"}}hello {{.}}{{end}}`), 0644))
+
+	mapFS := fstest.MapFS{
+		"a.tmpl": {Data: []byte(`a: {{.}}`)},
+		"b.tmpl": {Data: []byte(`b: {{.}}`)},
+	}
+
+	items := []map[string]interface{}{{"name": "foo"}, {"name": "bar"}}
+
+	tests := []formatTestCase{
+		{
+			name: "template from file",
+			cfg:  Config{Format: "gotemplate", Template: "@" + filepath.Join(dir, "main.tmpl"), TemplateFiles: []string{filepath.Join(dir, "greet.tmpl")}},
+			v:    "world",
+			want: "hello world!",
+		},
+		{
+			name: "template from file via format",
+			cfg:  Config{Format: "gotemplate=@" + filepath.Join(dir, "main.tmpl"), TemplateFiles: []string{filepath.Join(dir, "*.tmpl")}},
+			v:    "world",
+			want: "hello world!",
+		},
+		{
+			name: "nonexistent template file",
+			cfg:  Config{Format: "gotemplate", Template: "@" + filepath.Join(dir, "nonexistent.tmpl")},
+			err:  errors.New("failed to read template: open " + filepath.Join(dir, "nonexistent.tmpl") + ": no such file or directory"),
+		},
+		{
+			name: "inline template with partials",
+			cfg:  Config{Format: "gotemplate", Template: `{{template "greet" .}}?`, TemplateFiles: []string{filepath.Join(dir, "greet.tmpl")}},
+			v:    "world",
+			want: "hello world?",
+		},
+		{
+			name: "unmatched pattern",
+			cfg:  Config{Format: "gotemplate", TemplateFiles: []string{filepath.Join(dir, "*.nonexistent")}},
+			err:  errors.New("template: pattern matches no files: `" + filepath.Join(dir, "*.nonexistent") + "`"),
+		},
+		{
+			name: "first file is executed without template",
+			cfg:  Config{Format: "gotemplate", TemplateFS: mapFS, TemplateFiles: []string{"*.tmpl"}},
+			v:    "foo",
+			want: "a: foo",
+		},
+		{
+			name: "template name",
+			cfg:  Config{Format: "gotemplate", TemplateFS: mapFS, TemplateFiles: []string{"*.tmpl"}, TemplateName: "b.tmpl"},
+			v:    "foo",
+			want: "b: foo",
+		},
+		{
+			name: "undefined template name",
+			cfg:  Config{Format: "gotemplate", TemplateFS: mapFS, TemplateFiles: []string{"*.tmpl"}, TemplateName: "c.tmpl"},
+			err:  errors.New(`template "c.tmpl" not defined`),
+		},
+		{
+			name: "embedded template set",
+			cfg: Config{
+				Format:        "gotemplate",
+				Template:      "@testdata/templates/report.tmpl",
+				TemplateFiles: []string{"testdata/templates/partials.tmpl"},
+				TemplateFS:    templatesFS,
+			},
+			v:    items,
+			want: "- foo\n- bar\n",
+		},
+		{
+			name: "embedded partials with template items",
+			cfg: Config{
+				Format:        "gotemplate",
+				Template:      `{{template "item" .}}`,
+				TemplateFiles: []string{"testdata/templates/*.tmpl"},
+				TemplateFS:    templatesFS,
+				TemplateItems: true,
+			},
+			v:    items,
+			want: "- foo\n- bar",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
+
+type templateTestUser struct {
+	Name  string
+	Email string
+}
+
+func TestTemplateError(t *testing.T) {
+	mapFS := fstest.MapFS{
+		"partials.tmpl": {Data: []byte("{{define \"greet\"}}\n  hello {{.nmae}}\n{{end}}")},
+	}
+
+	v := map[string]interface{}{
+		"user":  map[string]interface{}{"name": "foo", "email": "foo@example.com"},
+		"items": []interface{}{map[string]interface{}{"id": 1}},
+	}
+
+	tests := []formatTestCase{
+		{
+			name: "parse error",
+			cfg:  Config{Format: "gotemplate", Template: "{{ .user"},
+			err:  errors.New("template: line 1: unclosed action\n  1 | {{ .user"),
+		},
+		{
+			name: "missing key without strict mode",
+			cfg:  Config{Format: "gotemplate", Template: "{{.user.nmae}}"},
+			v:    v,
+			want: "<no value>",
+		},
+		{
+			name: "missing key with suggestion",
+			cfg:  Config{Format: "gotemplate", Template: "Hello\n\t{{ .user.nmae }}!", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v,
+			err: errors.New(`template: line 2, column 10: map has no entry for key "nmae"
+  2 | 	{{ .user.nmae }}!
+    | 	        ^
+available keys: email, name
+did you mean "name"?`),
+		},
+		{
+			name: "missing key without suggestion",
+			cfg:  Config{Format: "gotemplate", Template: "{{.user.id}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v,
+			err: errors.New(`template: line 1, column 8: map has no entry for key "id"
+  1 | {{.user.id}}
+    |        ^
+available keys: email, name`),
+		},
+		{
+			name: "missing struct field",
+			cfg:  Config{Format: "gotemplate", Template: "{{.name}}"},
+			v:    templateTestUser{},
+			err: errors.New(`template: line 1, column 3: can't evaluate field name in type output.templateTestUser
+  1 | {{.name}}
+    |   ^
+available keys: Email, Name
+did you mean "Name"?`),
+		},
+		{
+			name: "dot changed by range",
+			cfg:  Config{Format: "gotemplate", Template: "{{range .items}}{{.name}}{{end}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v,
+			err: errors.New(`template: line 1, column 19: map has no entry for key "name"
+  1 | {{range .items}}{{.name}}{{end}}
+    |                   ^
+available keys: id`),
+		},
+		{
+			name: "range reports the first item lacking the key",
+			cfg:  Config{Format: "gotemplate", Template: "{{range $i, $e := .}}{{.cont}}{{end}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v: []interface{}{
+				map[string]interface{}{"cont": 1},
+				map[string]interface{}{"count": 2},
+			},
+			err: errors.New(`template: line 1, column 24: map has no entry for key "cont"
+  1 | {{range $i, $e := .}}{{.cont}}{{end}}
+    |                        ^
+available keys: count
+did you mean "count"?`),
+		},
+		{
+			name: "unrelated keys are not suggested",
+			cfg:  Config{Format: "gotemplate", Template: "{{range .items}}{{.foo}}{{end}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    map[string]interface{}{"items": []interface{}{map[string]interface{}{"bar": 1}}},
+			err: errors.New(`template: line 1, column 19: map has no entry for key "foo"
+  1 | {{range .items}}{{.foo}}{{end}}
+    |                   ^
+available keys: bar`),
+		},
+		{
+			name: "dot changed by with",
+			cfg:  Config{Format: "gotemplate", Template: "{{with .user}}{{if .name}}{{.mail}}{{end}}{{end}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v,
+			err: errors.New(`template: line 1, column 29: map has no entry for key "mail"
+  1 | {{with .user}}{{if .name}}{{.mail}}{{end}}{{end}}
+    |                             ^
+available keys: email, name
+did you mean "email"?`),
+		},
+		{
+			name: "dot set by a function is unknown",
+			cfg:  Config{Format: "gotemplate", Template: "{{range slice .items 0}}{{.name}}{{end}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v,
+			err: errors.New(`template: line 1, column 27: map has no entry for key "name"
+  1 | {{range slice .items 0}}{{.name}}{{end}}
+    |                           ^`),
+		},
+		{
+			name: "column counts characters",
+			cfg:  Config{Format: "gotemplate", Template: "äöü {{.usr}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v,
+			err: errors.New(`template: line 1, column 7: map has no entry for key "usr"
+  1 | äöü {{.usr}}
+    |       ^
+available keys: items, user
+did you mean "user"?`),
+		},
+		{
+			name: "root variable in range",
+			cfg:  Config{Format: "gotemplate", Template: "{{range .items}}{{$.usr}}{{end}}", TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v,
+			err: errors.New(`template: line 1, column 20: map has no entry for key "usr"
+  1 | {{range .items}}{{$.usr}}{{end}}
+    |                    ^
+available keys: items, user
+did you mean "user"?`),
+		},
+		{
+			name: "template items",
+			cfg:  Config{Format: "gotemplate", Template: "{{.ID}}", TemplateItems: true, TemplateConfig: TemplateConfig{Strict: true}},
+			v:    v["items"],
+			err: errors.New(`template: line 1, column 3: map has no entry for key "ID"
+  1 | {{.ID}}
+    |   ^
+available keys: id
+did you mean "id"?`),
+		},
+		{
+			name: "error in template file",
+			cfg: Config{
+				Format:         "gotemplate",
+				Template:       `{{template "greet" .user}}`,
+				TemplateFiles:  []string{"*.tmpl"},
+				TemplateFS:     mapFS,
+				TemplateConfig: TemplateConfig{Strict: true},
+			},
+			v: v,
+			err: errors.New(`template "partials.tmpl": line 2, column 11: map has no entry for key "nmae"
+  2 |   hello {{.nmae}}
+    |           ^`),
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+
+	t.Run("unwraps to exec error", func(t *testing.T) {
+		_, err := FormatString(templateTestUser{}, &Config{Format: "gotemplate", Template: "{{.name}}"})
+
+		var templateErr *TemplateError
+		require.True(t, errors.As(err, &templateErr))
+		require.Equal(t, "Name", templateErr.Suggestions[0])
+
+		var execErr template.ExecError
+		require.True(t, errors.As(err, &execErr))
+	})
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"name", "namespace", "labels", "Kind"}
+
+	require.Equal(t, []string{"name"}, suggest("nmae", candidates))
+	require.Equal(t, []string{"name"}, suggest("Name", candidates))
+	require.Equal(t, []string{"Kind"}, suggest("kind", candidates))
+	require.Equal(t, []string{"labels"}, suggest("lables", candidates))
+	require.Nil(t, suggest("foo", candidates))
+	require.Nil(t, suggest("foo", []string{"bar", "baz"}))
+	require.Equal(t, []string{"bar", "baz"}, suggest("bax", []string{"bar", "baz", "qux"}))
+}
@@ -0,0 +1,68 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var tomlOptions = []Option{
+	{Name: "indent", Type: IntOption, Default: 2, Usage: "number of spaces used to indent nested tables", Min: intBound(0)},
+}
+
+// formatTOML renders v as a TOML document. Since TOML documents are tables, v
+// must be an object. Null values are omitted as TOML has no representation
+// for them.
+func formatTOML(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(tomlOptions, config)
+
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := nv.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("toml: top-level value must be an object, got %T", v)
+	}
+
+	var buf bytes.Buffer
+
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = strings.Repeat(" ", opts.Int("indent"))
+
+	if err := enc.Encode(removeNulls(m)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// removeNulls recursively removes null values from objects and arrays of
+// normalized values.
+func removeNulls(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if value != nil {
+				m[key] = removeNulls(value)
+			}
+		}
+
+		return m
+	case []interface{}:
+		s := make([]interface{}, 0, len(v))
+		for _, value := range v {
+			if value != nil {
+				s = append(s, removeNulls(value))
+			}
+		}
+
+		return s
+	default:
+		return v
+	}
+}
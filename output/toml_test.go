@@ -0,0 +1,42 @@
+package output
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormatTOML(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "non-object",
+			cfg:  Config{Format: "toml"},
+			v:    []int{1, 2},
+			err:  errors.New("toml: top-level value must be an object, got []int"),
+		},
+		{
+			name: "struct",
+			cfg:  Config{Format: "toml"},
+			v:    tableTestItem{Name: "foo", Age: 42, Labels: map[string]string{"a": "b"}},
+			want: "age = 42\nname = \"foo\"\n\n[labels]\n  a = \"b\"\n",
+		},
+		{
+			name: "nested values and nulls",
+			cfg:  Config{Format: "toml", FormatOptions: OptionValues{"indent": 0}},
+			v: map[string]interface{}{
+				"float": 1.5,
+				"list":  []interface{}{1, nil, 2},
+				"null":  nil,
+				"items": []map[string]interface{}{{"a": 1}, {"b": "c"}},
+			},
+			want: "float = 1.5\nlist = [1, 2]\n\n[[items]]\na = 1\n\n[[items]]\nb = \"c\"\n",
+		},
+		{
+			name: "negative indent",
+			cfg:  Config{Format: "toml", FormatOptions: OptionValues{"indent": -1}},
+			v:    map[string]interface{}{"a": 1},
+			err:  errors.New(`invalid value -1 for int option "indent": must be at least 0`),
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
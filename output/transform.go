@@ -0,0 +1,365 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/martinohmann/exp/jsonpath"
+	"github.com/mitchellh/pointerstructure"
+)
+
+// transform is the compiled transformation pipeline of a Config. It is
+// applied to the selected value before it is passed on to the formatter. The
+// stages are applied in the following order: filter, sort, select, unique,
+// offset and limit.
+type transform struct {
+	filter   *jsonpath.Filter
+	sortBy   *pointerstructure.Pointer
+	sortDesc bool
+	selects  []*pointerstructure.Pointer
+	unique   bool
+	offset   int
+	limit    int
+}
+
+// compileTransform parses the transformation stages of config. Returns nil if
+// config does not configure any stage.
+func compileTransform(config *Config) (*transform, error) {
+	var (
+		t   transform
+		err error
+	)
+
+	if config.Filter != "" {
+		t.filter, err = jsonpath.ParseFilter(config.Filter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.SortBy != "" {
+		t.sortBy, err = parseFieldPointer(config.SortBy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sort key: %w", err)
+		}
+	}
+
+	for _, field := range config.Select {
+		p, err := parseFieldPointer(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid select field: %w", err)
+		}
+
+		t.selects = append(t.selects, p)
+	}
+
+	if config.Offset < 0 {
+		return nil, fmt.Errorf("offset must not be negative, got %d", config.Offset)
+	}
+
+	if config.Limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative, got %d", config.Limit)
+	}
+
+	t.sortDesc = config.SortDescending
+	t.unique = config.Unique
+	t.offset = config.Offset
+	t.limit = config.Limit
+
+	if !t.itemsOnly() && t.selects == nil {
+		return nil, nil
+	}
+
+	return &t, nil
+}
+
+// parseFieldPointer parses a JSON pointer. In contrast to
+// pointerstructure.Parse the leading slash is optional.
+func parseFieldPointer(s string) (*pointerstructure.Pointer, error) {
+	if !strings.HasPrefix(s, "/") {
+		s = "/" + s
+	}
+
+	return pointerstructure.Parse(s)
+}
+
+// itemsOnly returns true if t contains stages which can only be applied to
+// slices.
+func (t *transform) itemsOnly() bool {
+	return t.filter != nil || t.sortBy != nil || t.unique || t.offset > 0 || t.limit > 0
+}
+
+// streamable returns true if t only contains stages which can be applied to
+// each item of a stream individually.
+func (t *transform) streamable() bool {
+	return t.sortBy == nil && !t.unique && t.offset == 0 && t.limit == 0
+}
+
+// columns returns the top-level keys of the selected fields in the order they
+// were selected.
+func (t *transform) columns() []string {
+	var keys keySet
+
+	for _, p := range t.selects {
+		if len(p.Parts) > 0 {
+			keys.add(p.Parts[0])
+		}
+	}
+
+	return keys.keys
+}
+
+// transformItem is an item of the slice that is transformed.
+type transformItem struct {
+	// value is the item that is passed on to the formatter. It is the
+	// original value unless fields were selected, which retains struct
+	// tags like `output:",wide"` for table-like formats.
+	value interface{}
+	// normalized is the JSON representation of value.
+	normalized interface{}
+}
+
+// apply applies all stages to v. Returns an error if v is not a slice but
+// stages other than select are configured.
+func (t *transform) apply(v interface{}) (interface{}, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		if t.itemsOnly() {
+			return nil, fmt.Errorf("cannot filter, sort, deduplicate or limit value of type %T: value is not a slice", v)
+		}
+
+		nv, err := normalize(v)
+		if err != nil {
+			return nil, err
+		}
+
+		return t.project(nv), nil
+	}
+
+	items := make([]transformItem, 0, rv.Len())
+
+	for i := 0; i < rv.Len(); i++ {
+		item := rv.Index(i).Interface()
+
+		nv, err := normalize(item)
+		if err != nil {
+			return nil, err
+		}
+
+		if t.filter != nil && !t.filter.Match(nv) {
+			continue
+		}
+
+		items = append(items, transformItem{value: item, normalized: nv})
+	}
+
+	if t.sortBy != nil {
+		t.sort(items)
+	}
+
+	if t.selects != nil {
+		for i, item := range items {
+			pv := t.project(item.normalized)
+			items[i] = transformItem{value: pv, normalized: pv}
+		}
+	}
+
+	if t.unique {
+		var err error
+
+		items, err = uniqueItems(items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.offset >= len(items) {
+		items = items[:0]
+	} else {
+		items = items[t.offset:]
+	}
+
+	if t.limit > 0 && t.limit < len(items) {
+		items = items[:t.limit]
+	}
+
+	values := make([]interface{}, len(items))
+	for i, item := range items {
+		values[i] = item.value
+	}
+
+	return values, nil
+}
+
+// applyItem applies the filter and select stages to a single item of a
+// stream. Returns false if the item does not match the filter.
+func (t *transform) applyItem(v interface{}) (interface{}, bool, error) {
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if t.filter != nil && !t.filter.Match(nv) {
+		return nil, false, nil
+	}
+
+	if t.selects == nil {
+		return v, true, nil
+	}
+
+	return t.project(nv), true, nil
+}
+
+// sort sorts items stably by the value the sortBy pointer yields for them.
+func (t *transform) sort(items []transformItem) {
+	keys := make([]interface{}, len(items))
+	for i, item := range items {
+		// Items without the key yield nil which sorts first.
+		keys[i], _ = t.sortBy.Get(item.normalized)
+	}
+
+	idx := make([]int, len(items))
+	for i := range idx {
+		idx[i] = i
+	}
+
+	sort.SliceStable(idx, func(i, j int) bool {
+		c := compareValues(keys[idx[i]], keys[idx[j]])
+		if t.sortDesc {
+			return c > 0
+		}
+
+		return c < 0
+	})
+
+	sorted := make([]transformItem, len(items))
+	for i, j := range idx {
+		sorted[i] = items[j]
+	}
+
+	copy(items, sorted)
+}
+
+// project builds a map which only contains the selected fields of the
+// normalized value nv. Fields which do not exist in nv are omitted.
+func (t *transform) project(nv interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+
+	for _, p := range t.selects {
+		fv, err := p.Get(nv)
+		if err != nil || len(p.Parts) == 0 {
+			continue
+		}
+
+		setField(result, p.Parts, fv)
+	}
+
+	return result
+}
+
+// setField sets the value at the path described by parts in m, creating
+// intermediate maps as needed.
+func setField(m map[string]interface{}, parts []string, v interface{}) {
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := m[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			m[part] = next
+		}
+
+		m = next
+	}
+
+	m[parts[len(parts)-1]] = v
+}
+
+// uniqueItems removes items whose JSON representation is equal to the one of
+// a previous item.
+func uniqueItems(items []transformItem) ([]transformItem, error) {
+	seen := make(map[string]bool, len(items))
+	result := items[:0]
+
+	for _, item := range items {
+		buf, err := json.Marshal(item.normalized)
+		if err != nil {
+			return nil, err
+		}
+
+		key := string(buf)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		result = append(result, item)
+	}
+
+	return result, nil
+}
+
+// compareValues compares two normalized values and returns -1, 0 or 1.
+// Values of different types are ordered as follows: null, booleans, numbers,
+// strings and everything else. Arrays and objects compare equal to each
+// other.
+func compareValues(a, b interface{}) int {
+	ra, rb := valueRank(a), valueRank(b)
+	if ra != rb {
+		return compareInts(ra, rb)
+	}
+
+	switch a := a.(type) {
+	case bool:
+		if a == b.(bool) {
+			return 0
+		} else if a {
+			return 1
+		}
+
+		return -1
+	case json.Number:
+		fa, _ := a.Float64()
+		fb, _ := b.(json.Number).Float64()
+
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		default:
+			return 0
+		}
+	case string:
+		return strings.Compare(a, b.(string))
+	default:
+		return 0
+	}
+}
+
+func valueRank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case json.Number:
+		return 2
+	case string:
+		return 3
+	default:
+		return 4
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,127 @@
+package output
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFormat_transform(t *testing.T) {
+	items := []interface{}{
+		map[string]interface{}{"name": "foo", "count": 2, "labels": map[string]interface{}{"app": "a", "tier": "web"}},
+		map[string]interface{}{"name": "bar", "count": 10, "labels": map[string]interface{}{"app": "b"}},
+		map[string]interface{}{"name": "baz", "labels": map[string]interface{}{"app": "a", "tier": "db"}},
+		map[string]interface{}{"name": "qux", "count": 2, "labels": map[string]interface{}{"app": "b"}},
+	}
+
+	users := []fieldsTestUser{
+		{Name: "foo", Email: "foo@example.com"},
+		{Name: "bar", Email: "bar@example.com"},
+	}
+
+	compact := OptionValues{"compact": true}
+
+	tests := []formatTestCase{
+		{
+			name: "filter",
+			cfg:  Config{Format: "json", FormatOptions: compact, Filter: "@.count >= 2 && @.labels.app == 'b'", Select: []string{"name"}},
+			v:    items,
+			want: `[{"name":"bar"},{"name":"qux"}]`,
+		},
+		{
+			name: "sort by number is stable and puts missing keys first",
+			cfg:  Config{Format: "json", FormatOptions: compact, SortBy: "count", Select: []string{"name"}},
+			v:    items,
+			want: `[{"name":"baz"},{"name":"foo"},{"name":"qux"},{"name":"bar"}]`,
+		},
+		{
+			name: "sort descending by nested key",
+			cfg:  Config{Format: "json", FormatOptions: compact, SortBy: "/labels/tier", SortDescending: true, Select: []string{"name"}},
+			v:    items,
+			want: `[{"name":"foo"},{"name":"baz"},{"name":"bar"},{"name":"qux"}]`,
+		},
+		{
+			name: "select preserves nesting and omits missing fields",
+			cfg:  Config{Format: "json", FormatOptions: compact, Select: []string{"name", "labels/tier"}},
+			v:    items[1:3],
+			want: `[{"name":"bar"},{"labels":{"tier":"db"},"name":"baz"}]`,
+		},
+		{
+			name: "select on non-slice value",
+			cfg:  Config{Format: "json", FormatOptions: compact, Select: []string{"/count"}},
+			v:    items[0],
+			want: `{"count":2}`,
+		},
+		{
+			name: "unique after select",
+			cfg:  Config{Format: "json", FormatOptions: compact, Select: []string{"labels/app"}, Unique: true},
+			v:    items,
+			want: `[{"labels":{"app":"a"}},{"labels":{"app":"b"}}]`,
+		},
+		{
+			name: "offset and limit",
+			cfg:  Config{Format: "json", FormatOptions: compact, SortBy: "name", Offset: 1, Limit: 2, Select: []string{"name"}},
+			v:    items,
+			want: `[{"name":"baz"},{"name":"foo"}]`,
+		},
+		{
+			name: "offset beyond end",
+			cfg:  Config{Format: "json", FormatOptions: compact, Offset: 10},
+			v:    items,
+			want: `[]`,
+		},
+		{
+			name: "applied after json pointer",
+			cfg:  Config{Format: "json", FormatOptions: compact, JSONPointer: "/items", Limit: 1, Select: []string{"name"}},
+			v:    map[string]interface{}{"items": items},
+			want: `[{"name":"foo"}]`,
+		},
+		{
+			name: "table columns follow select order",
+			cfg:  Config{Format: "table", Select: []string{"name", "count", "labels/app", "/name"}},
+			v:    items,
+			want: "NAME   COUNT   LABELS\nfoo        2   {\"app\":\"a\"}\nbar       10   {\"app\":\"b\"}\nbaz            {\"app\":\"a\"}\nqux        2   {\"app\":\"b\"}\n",
+		},
+		{
+			name: "csv columns follow select order",
+			cfg:  Config{Format: "csv", Select: []string{"count", "name"}},
+			v:    items,
+			want: "count,name\n2,foo\n10,bar\n,baz\n2,qux\n",
+		},
+		{
+			name: "markdown columns follow select order",
+			cfg:  Config{Format: "markdown", Select: []string{"name", "count"}, Limit: 1},
+			v:    items,
+			want: "| name | count |\n| --- | --: |\n| foo | 2 |\n",
+		},
+		{
+			name: "explicit columns take precedence over select order",
+			cfg:  Config{Format: "csv", Select: []string{"name", "count"}, Columns: []string{"count", "name"}, Limit: 1},
+			v:    items,
+			want: "count,name\n2,foo\n",
+		},
+		{
+			name: "retains struct tags if nothing is selected",
+			cfg:  Config{Format: "table", Wide: true, SortBy: "name"},
+			v:    users,
+			want: "USER   E-MAIL            TOKEN   PIN\nbar    bar@example.com\nfoo    foo@example.com\n",
+		},
+		{
+			name: "non-slice value",
+			cfg:  Config{Format: "json", FormatOptions: compact, Limit: 1},
+			v:    items[0],
+			err:  errors.New("cannot filter, sort, deduplicate or limit value of type map[string]interface {}: value is not a slice"),
+		},
+		{
+			name: "invalid filter",
+			cfg:  Config{Format: "json", FormatOptions: compact, Filter: "@.count >"},
+			err:  errors.New(`invalid filter expression "@.count >": expected operand at end of input`),
+		},
+		{
+			name: "negative limit",
+			cfg:  Config{Format: "json", FormatOptions: compact, Limit: -1},
+			err:  errors.New("limit must not be negative, got -1"),
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,207 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var treeOptions = []Option{
+	{Name: "depth", Type: IntOption, Default: 0, Usage: "maximum depth of nested values to expand, 0 means unlimited"},
+	{Name: "max-items", Type: IntOption, Default: 0, Usage: "maximum number of array items to show before collapsing the rest, 0 means unlimited"},
+}
+
+const (
+	treeBranch     = "├── "
+	treeLastBranch = "└── "
+	treeIndent     = "│   "
+	treeLastIndent = "    "
+)
+
+// formatTree renders v as a tree with box-drawing guides, similar to the
+// output of the `tree` command. Object keys are sorted, array items are
+// labeled with their index and leaf values are rendered as JSON, so that
+// the output is lossless unless the depth or max-items options are used.
+func formatTree(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(treeOptions, config)
+
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &treePrinter{
+		depth:    opts.Int("depth"),
+		maxItems: opts.Int("max-items"),
+		colored:  config.Color == ColorAlways,
+	}
+
+	if treeLen(nv) == 0 {
+		p.buf.WriteString(p.leaf(nv))
+		p.buf.WriteByte('\n')
+		return p.buf.Bytes(), nil
+	}
+
+	p.buf.WriteString(".\n")
+	p.printChildren(nv, "", 1)
+
+	return p.buf.Bytes(), nil
+}
+
+// treePrinter renders normalized values as a tree.
+type treePrinter struct {
+	buf      bytes.Buffer
+	depth    int
+	maxItems int
+	colored  bool
+}
+
+// treeNode is a child of an object or array.
+type treeNode struct {
+	label string
+	value interface{}
+}
+
+// printChildren prints the children of the object or array v. Each line is
+// prefixed with prefix, depth is the depth of the children.
+func (p *treePrinter) printChildren(v interface{}, prefix string, depth int) {
+	nodes, more := p.children(v)
+
+	for i, node := range nodes {
+		branch, indent := treeBranch, treeIndent
+		if i == len(nodes)-1 && more == 0 {
+			branch, indent = treeLastBranch, treeLastIndent
+		}
+
+		p.buf.WriteString(prefix)
+		p.buf.WriteString(branch)
+		p.buf.WriteString(node.label)
+
+		switch {
+		case treeLen(node.value) == 0:
+			p.buf.WriteString(": ")
+			p.buf.WriteString(p.leaf(node.value))
+			p.buf.WriteByte('\n')
+		case p.depth > 0 && depth >= p.depth:
+			p.buf.WriteString(": ")
+			p.buf.WriteString(p.dim(treeSummary(node.value)))
+			p.buf.WriteByte('\n')
+		default:
+			p.buf.WriteByte('\n')
+			p.printChildren(node.value, prefix+indent, depth+1)
+		}
+	}
+
+	if more > 0 {
+		p.buf.WriteString(prefix)
+		p.buf.WriteString(treeLastBranch)
+		p.buf.WriteString(p.dim(fmt.Sprintf("… %d more %s", more, plural(more, "item"))))
+		p.buf.WriteByte('\n')
+	}
+}
+
+// children returns the labeled children of the object or array v. For arrays
+// with more than maxItems items, the number of omitted items is returned as
+// well.
+func (p *treePrinter) children(v interface{}) (nodes []treeNode, more int) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(v)
+		nodes = make([]treeNode, len(keys))
+
+		for i, key := range keys {
+			nodes[i] = treeNode{label: p.key(key), value: v[key]}
+		}
+	case []interface{}:
+		items := v
+
+		if p.maxItems > 0 && len(items) > p.maxItems {
+			items, more = items[:p.maxItems], len(items)-p.maxItems
+		}
+
+		nodes = make([]treeNode, len(items))
+
+		for i, item := range items {
+			nodes[i] = treeNode{label: fmt.Sprintf("[%d]", i), value: item}
+		}
+	}
+
+	return nodes, more
+}
+
+// key renders an object key. Keys which could be mistaken for array indices
+// or which contain separators or control characters are quoted.
+func (p *treePrinter) key(key string) string {
+	if key == "" || strings.HasPrefix(key, "[") || strings.HasPrefix(key, `"`) ||
+		strings.Contains(key, ": ") || strconv.Quote(key) != `"`+key+`"` {
+		key = strconv.Quote(key)
+	}
+
+	if p.colored {
+		return keyColor(key)
+	}
+
+	return key
+}
+
+// leaf renders a scalar, an empty object or an empty array as JSON.
+func (p *treePrinter) leaf(v interface{}) string {
+	s := compactJSON(v)
+
+	if !p.colored {
+		return s
+	}
+
+	switch v.(type) {
+	case nil:
+		return nullColor(s)
+	case bool:
+		return boolColor(s)
+	case json.Number:
+		return numberColor(s)
+	case string:
+		return stringColor(s)
+	default:
+		return s
+	}
+}
+
+func (p *treePrinter) dim(s string) string {
+	if p.colored {
+		return nullColor(s)
+	}
+
+	return s
+}
+
+// treeLen returns the number of children of v. Returns 0 for scalars.
+func treeLen(v interface{}) int {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v)
+	case []interface{}:
+		return len(v)
+	default:
+		return 0
+	}
+}
+
+// treeSummary summarizes an object or array which is not expanded, e.g.
+// `{2 keys}` or `[3 items]`.
+func treeSummary(v interface{}) string {
+	if _, ok := v.(map[string]interface{}); ok {
+		return fmt.Sprintf("{%d %s}", treeLen(v), plural(treeLen(v), "key"))
+	}
+
+	return fmt.Sprintf("[%d %s]", treeLen(v), plural(treeLen(v), "item"))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+
+	return noun + "s"
+}
@@ -0,0 +1,92 @@
+package output
+
+import (
+	"testing"
+)
+
+func TestFormatTree(t *testing.T) {
+	v := map[string]interface{}{
+		"name": "foo",
+		"spec": map[string]interface{}{
+			"replicas": 3,
+			"ports":    []interface{}{80, 443, 8080},
+			"labels":   map[string]interface{}{"app": "foo", "a: b": nil},
+			"empty":    map[string]interface{}{},
+		},
+		"tags": []interface{}{},
+		"ok":   true,
+	}
+
+	tests := []formatTestCase{
+		{
+			name: "nested value",
+			cfg:  Config{Format: "tree"},
+			v:    v,
+			want: `.
+├── name: "foo"
+├── ok: true
+├── spec
+│   ├── empty: {}
+│   ├── labels
+│   │   ├── "a: b": null
+│   │   └── app: "foo"
+│   ├── ports
+│   │   ├── [0]: 80
+│   │   ├── [1]: 443
+│   │   └── [2]: 8080
+│   └── replicas: 3
+└── tags: []
+`,
+		},
+		{
+			name: "depth",
+			cfg:  Config{Format: "tree", FormatOptions: OptionValues{"depth": 2}},
+			v:    v,
+			want: `.
+├── name: "foo"
+├── ok: true
+├── spec
+│   ├── empty: {}
+│   ├── labels: {2 keys}
+│   ├── ports: [3 items]
+│   └── replicas: 3
+└── tags: []
+`,
+		},
+		{
+			name: "max items",
+			cfg:  Config{Format: "tree", FormatOptions: OptionValues{"max-items": 2}},
+			v:    []interface{}{"a", "b", "c", "d"},
+			want: `.
+├── [0]: "a"
+├── [1]: "b"
+└── … 2 more items
+`,
+		},
+		{
+			name: "scalar",
+			cfg:  Config{Format: "tree"},
+			v:    "foo\nbar",
+			want: "\"foo\\nbar\"\n",
+		},
+		{
+			name: "structs",
+			cfg:  Config{Format: "tree"},
+			v:    []fieldsTestUser{{Name: "foo", Password: "secret"}},
+			want: `.
+└── [0]
+    ├── email: ""
+    ├── name: "foo"
+    └── password: "***"
+`,
+		},
+		{
+			name: "colored",
+			cfg:  Config{Format: "tree", Color: ColorAlways},
+			v:    map[string]interface{}{"a": []interface{}{1, "b", nil}},
+			want: ".\n└── " + keyColor("a") + "\n    ├── [0]: " + numberColor("1") + "\n    ├── [1]: " + stringColor(`"b"`) + "\n    └── [2]: " + nullColor("null") + "\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,156 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// normalize converts v into its generic representation consisting only of
+// map[string]interface{}, []interface{}, string, json.Number, bool and nil
+// values. The conversion is done by a JSON roundtrip, which means that struct
+// fields are named according to their json tags.
+func normalize(v interface{}) (interface{}, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	dec.UseNumber()
+
+	var nv interface{}
+
+	if err := dec.Decode(&nv); err != nil {
+		return nil, err
+	}
+
+	return nv, nil
+}
+
+// indirect dereferences rv until it reaches a value that is neither a pointer
+// nor an interface.
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv
+		}
+
+		rv = rv.Elem()
+	}
+
+	return rv
+}
+
+// indirectType dereferences t until it reaches a type that is not a pointer.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// structFieldKeys returns the keys of the fields of struct type t in
+// declaration order. The keys are the names that encoding/json would use for
+// the respective fields. Fields of embedded structs without a json tag are
+// inlined.
+func structFieldKeys(t reflect.Type) []string {
+	fields := structFields(t)
+
+	keys := make([]string, len(fields))
+	for i, field := range fields {
+		keys[i] = field.key
+	}
+
+	return keys
+}
+
+// parseJSONTag splits a json struct tag into the name and its options.
+func parseJSONTag(tag string) (name string, opts string) {
+	if tag == "-" {
+		return tag, ""
+	}
+
+	if idx := strings.Index(tag, ","); idx != -1 {
+		return tag[:idx], tag[idx+1:]
+	}
+
+	return tag, ""
+}
+
+// objectKeys returns the keys of v in a stable order if v is a map or a
+// struct. For structs this is the field declaration order, map keys are
+// sorted. Returns false if v is neither a map nor a struct.
+func objectKeys(v interface{}) ([]string, bool) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || !isObjectType(rv.Type()) {
+		return nil, false
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct:
+		return structFieldKeys(rv.Type()), true
+	case reflect.Map:
+		keys := make([]string, 0, rv.Len())
+		for _, key := range rv.MapKeys() {
+			keys = append(keys, stringify(key.Interface()))
+		}
+
+		sort.Strings(keys)
+		return keys, true
+	default:
+		return nil, false
+	}
+}
+
+// isEmptyValue returns true if the normalized value v is nil, false, zero or
+// an empty string, object or array.
+func isEmptyValue(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case json.Number:
+		f, err := v.Float64()
+		return err == nil && f == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+// stringify converts a normalized value into its string representation.
+// Strings are returned as is, nil values yield an empty string and nested
+// maps and slices are encoded as compact JSON.
+func stringify(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]interface{}, []interface{}:
+		buf, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+
+		return string(buf)
+	default:
+		return fmt.Sprint(v)
+	}
+}
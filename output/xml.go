@@ -0,0 +1,110 @@
+package output
+
+import (
+	"bytes"
+	"encoding/xml"
+	"regexp"
+	"strings"
+)
+
+var xmlOptions = []Option{
+	{Name: "root", Type: StringOption, Default: "root", Usage: "name of the root element"},
+	{Name: "item", Type: StringOption, Default: "item", Usage: "name of the elements wrapping array items"},
+	{Name: "indent", Type: IntOption, Default: 2, Usage: "number of spaces used for indentation, 0 disables indentation"},
+	{Name: "header", Type: BoolOption, Default: true, Usage: "write the xml declaration"},
+}
+
+// xmlNameRegexp matches keys that can be used as element names as is.
+var xmlNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// formatXML renders v as an XML document. Unlike xml.Marshal it supports
+// generic values like maps and slices: v is normalized and wrapped in a root
+// element. Object keys become element names, array items are wrapped in item
+// elements and scalars become character data. Keys which are not valid
+// element names are rendered as `<entry key="...">` elements.
+func formatXML(v interface{}, config *Config) ([]byte, error) {
+	opts := optionValues(xmlOptions, config)
+
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if opts.Bool("header") {
+		buf.WriteString(xml.Header)
+	}
+
+	enc := xml.NewEncoder(&buf)
+
+	if n := opts.Int("indent"); n > 0 {
+		enc.Indent("", strings.Repeat(" ", n))
+	}
+
+	x := &xmlEncoder{Encoder: enc, item: opts.String("item")}
+
+	if err := x.encodeElement(opts.String("root"), nv); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+type xmlEncoder struct {
+	*xml.Encoder
+	item string
+}
+
+func (e *xmlEncoder) encodeElement(name string, v interface{}) error {
+	start := xmlStartElement(name)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for _, key := range sortedKeys(v) {
+			if err := e.encodeElement(key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := e.encodeElement(e.item, item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	default:
+		if err := e.EncodeToken(xml.CharData(stringify(v))); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func xmlStartElement(name string) xml.StartElement {
+	if isXMLName(name) {
+		return xml.StartElement{Name: xml.Name{Local: name}}
+	}
+
+	return xml.StartElement{
+		Name: xml.Name{Local: "entry"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+	}
+}
+
+// isXMLName returns true if name is a valid element name. Names starting with
+// "xml" are reserved.
+func isXMLName(name string) bool {
+	return xmlNameRegexp.MatchString(name) && !strings.HasPrefix(strings.ToLower(name), "xml")
+}
@@ -0,0 +1,59 @@
+package output
+
+import "testing"
+
+func TestFormatXML(t *testing.T) {
+	tests := []formatTestCase{
+		{
+			name: "nil",
+			cfg:  Config{Format: "xml", FormatOptions: OptionValues{"header": false}},
+			want: "<root></root>\n",
+		},
+		{
+			name: "scalar",
+			cfg:  Config{Format: "xml"},
+			v:    "<foo> & bar",
+			want: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<root>&lt;foo&gt; &amp; bar</root>\n",
+		},
+		{
+			name: "generic map",
+			cfg:  Config{Format: "xml", FormatOptions: OptionValues{"header": false}},
+			v: map[string]interface{}{
+				"name":   "foo",
+				"count":  2,
+				"tags":   []string{"a", "b"},
+				"nested": map[string]interface{}{"ok": true, "null": nil},
+			},
+			want: `<root>
+  <count>2</count>
+  <name>foo</name>
+  <nested>
+    <null></null>
+    <ok>true</ok>
+  </nested>
+  <tags>
+    <item>a</item>
+    <item>b</item>
+  </tags>
+</root>
+`,
+		},
+		{
+			name: "custom root and item names without indentation",
+			cfg: Config{
+				Format:        "xml",
+				FormatOptions: OptionValues{"header": false, "root": "people", "item": "person", "indent": 0},
+			},
+			v:    []tableTestItem{{Name: "foo", Age: 42}},
+			want: "<people><person><age>42</age><name>foo</name></person></people>\n",
+		},
+		{
+			name: "invalid element names",
+			cfg:  Config{Format: "xml", FormatOptions: OptionValues{"header": false, "indent": 0}},
+			v:    map[string]string{"foo bar": "baz", "1st": "a", "xmlns": "b", `"q"`: "c"},
+			want: `<root><entry key="&#34;q&#34;">c</entry><entry key="1st">a</entry><entry key="foo bar">baz</entry><entry key="xmlns">b</entry></root>` + "\n",
+		},
+	}
+
+	testFormat(t, tests, FormatString)
+}
@@ -0,0 +1,108 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/ghodss/yaml"
+)
+
+// yamlPlainRegexp matches strings that can safely be written as plain
+// scalars in flow collections.
+var yamlPlainRegexp = regexp.MustCompile(`^[A-Za-z0-9_./-]+$`)
+
+// marshalYAMLFlow marshals v as YAML using flow style for all collections,
+// e.g. `{a: [1, 2], b: foo}`.
+func marshalYAMLFlow(v interface{}) ([]byte, error) {
+	nv, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if err := writeYAMLFlow(&buf, nv); err != nil {
+		return nil, err
+	}
+
+	buf.WriteByte('\n')
+
+	return buf.Bytes(), nil
+}
+
+func writeYAMLFlow(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		buf.WriteByte('{')
+
+		for i, key := range sortedKeys(v) {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+
+			if err := writeYAMLFlowString(buf, key); err != nil {
+				return err
+			}
+
+			buf.WriteString(": ")
+
+			if err := writeYAMLFlow(buf, v[key]); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte('}')
+	case []interface{}:
+		buf.WriteByte('[')
+
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+
+			if err := writeYAMLFlow(buf, item); err != nil {
+				return err
+			}
+		}
+
+		buf.WriteByte(']')
+	case string:
+		return writeYAMLFlowString(buf, v)
+	case nil:
+		buf.WriteString("null")
+	default:
+		buf.WriteString(stringify(v))
+	}
+
+	return nil
+}
+
+// writeYAMLFlowString writes s as a plain scalar if that is unambiguous,
+// otherwise as a double-quoted scalar.
+func writeYAMLFlowString(buf *bytes.Buffer, s string) error {
+	if yamlPlainRegexp.MatchString(s) {
+		// Ensure that the plain scalar is not interpreted as a value of
+		// another type like a number, bool or null.
+		out, err := yaml.Marshal(s)
+		if err != nil {
+			return err
+		}
+
+		if strings.TrimSuffix(string(out), "\n") == s {
+			buf.WriteString(s)
+			return nil
+		}
+	}
+
+	// JSON strings are valid double-quoted YAML scalars.
+	out, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	buf.Write(out)
+
+	return nil
+}